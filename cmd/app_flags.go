@@ -0,0 +1,146 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package cmd
+
+import (
+	"github.com/aurc/loggo/internal/buffer"
+	"github.com/aurc/loggo/internal/filter"
+	"github.com/aurc/loggo/internal/loggo"
+	"github.com/aurc/loggo/internal/reader"
+	"github.com/aurc/loggo/internal/util"
+	"github.com/spf13/cobra"
+)
+
+// addAppFlags registers the flags shared by every command that starts the
+// log viewer.
+func addAppFlags(cmd *cobra.Command) {
+	cmd.Flags().
+		IntP("max-entries", "", 0,
+			"Maximum number of log entries kept in memory (0 for unlimited)")
+	cmd.Flags().
+		StringP("max-memory", "", "",
+			`Approximate memory used to keep log entries, e.g. "512MB" or "1GB"
+(unlimited if not provided)`)
+	cmd.Flags().
+		BoolP("spill", "", false,
+			`Move entries beyond max-entries/max-memory to a temporary file on disk
+instead of discarding them, so they can still be browsed and filtered`)
+}
+
+// appOptions builds the log viewer options out of the flags registered by
+// addAppFlags.
+func appOptions(cmd *cobra.Command) []loggo.Option {
+	maxEntries, _ := cmd.Flags().GetInt("max-entries")
+	maxMemory, err := buffer.ParseSize(cmd.Flag("max-memory").Value.String())
+	if err != nil {
+		util.Log().Fatal("Invalid --max-memory flag: ", err)
+	}
+	spill, _ := cmd.Flags().GetBool("spill")
+	return []loggo.Option{
+		loggo.WithRetention(buffer.Options{
+			MaxEntries: maxEntries,
+			MaxMemory:  maxMemory,
+			Spill:      spill,
+		}),
+	}
+}
+
+// addLocalFilterFlag registers the --filter and --context flags of the
+// commands whose own flags don't already claim them.
+func addLocalFilterFlag(cmd *cobra.Command) {
+	cmd.Flags().
+		StringP("filter", "", "",
+			`Start with a local filter applied, either the name of a saved filter
+or an inline expression, e.g. "level = 'error'"`)
+	cmd.Flags().
+		IntP("context", "", 0,
+			"Show this many entries before and after each local filter match, like grep -C")
+}
+
+// localFilterOption resolves the flags registered by addLocalFilterFlag into
+// log viewer options.
+func localFilterOption(cmd *cobra.Command) []loggo.Option {
+	var opts []loggo.Option
+	if context, _ := cmd.Flags().GetInt("context"); context > 0 {
+		opts = append(opts, loggo.WithContext(context))
+	}
+	if expression := filterFlag(cmd, "filter"); len(expression) > 0 {
+		opts = append(opts, loggo.WithFilter(expression))
+	}
+	return opts
+}
+
+// filterFlag resolves a flag holding the name of a saved filter or an inline
+// filter expression, exiting if the expression is invalid.
+func filterFlag(cmd *cobra.Command, name string) string {
+	nameOrExpression, _ := cmd.Flags().GetString(name)
+	if len(nameOrExpression) == 0 {
+		return ""
+	}
+	expression := nameOrExpression
+	if lib, err := filter.DefaultLibrary(); err == nil {
+		expression = lib.Resolve(nameOrExpression)
+	}
+	if _, err := filter.ParseFilterExpression(expression); err != nil {
+		util.Log().Fatalf("Invalid --%s flag: %v", name, err)
+	}
+	return expression
+}
+
+// addSplitFlags registers the flags opening a second pane.
+func addSplitFlags(cmd *cobra.Command) {
+	cmd.Flags().
+		StringP("split", "", "",
+			`Start with a second pane, either "vertical" (side by side) or "horizontal"
+(one above the other)`)
+	cmd.Flags().
+		StringP("split-filter", "", "",
+			"Local filter of the second pane, either the name of a saved filter or an inline expression")
+	cmd.Flags().
+		BoolP("sync-scroll", "", false,
+			"Synchronise the scrolling of the panes by the time of their entries")
+}
+
+// splitOption resolves the flags registered by addSplitFlags into log viewer
+// options. The second pane shows the entries of reader, or of the main input
+// if nil.
+func splitOption(cmd *cobra.Command, reader reader.Reader) []loggo.Option {
+	var opts []loggo.Option
+	if sync, _ := cmd.Flags().GetBool("sync-scroll"); sync {
+		opts = append(opts, loggo.WithSyncScroll())
+	}
+	split, _ := cmd.Flags().GetString("split")
+	expression := filterFlag(cmd, "split-filter")
+	if len(split) == 0 && len(expression) == 0 && reader == nil {
+		return opts
+	}
+	var horizontal bool
+	switch split {
+	case "", "vertical":
+	case "horizontal":
+		horizontal = true
+	default:
+		util.Log().Fatalf("Invalid --split flag %q, expected vertical or horizontal", split)
+	}
+	return append(opts, loggo.WithSplit(reader, expression, horizontal))
+}
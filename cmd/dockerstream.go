@@ -0,0 +1,89 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package cmd
+
+import (
+	"github.com/aurc/loggo/internal/docker"
+	"github.com/aurc/loggo/internal/loggo"
+	"github.com/aurc/loggo/internal/reader"
+	"github.com/aurc/loggo/internal/util"
+	"github.com/spf13/cobra"
+)
+
+// dockerStreamCmd represents the docker-stream command
+var dockerStreamCmd = &cobra.Command{
+	Use:   "docker-stream [container...]",
+	Short: "Continuously stream Docker container logs",
+	Long: `Continuously stream the logs of running containers through
+the Docker Engine API socket. Containers are selected by name,
+ID, docker compose service name and/or label (all of them if
+none given), and containers started or restarted later are
+picked up as they start. Each entry is tagged with its
+'container' and 'stream' (stdout or stderr), so json output
+keeps parsing, unlike 'docker compose logs':
+
+	loggo docker-stream web worker
+	loggo docker-stream --label com.docker.compose.project=shop --since 10m
+	loggo docker-stream --host tcp://127.0.0.1:2375 3f4e8a`,
+	Run: func(cmd *cobra.Command, args []string) {
+		templateFile := cmd.Flag("template").Value.String()
+		host, _ := cmd.Flags().GetString("host")
+		client, err := docker.NewClient(host)
+		if err != nil {
+			util.Log().Fatal(err)
+		}
+		labels, _ := cmd.Flags().GetStringArray("label")
+		since, _ := cmd.Flags().GetDuration("since")
+		reader := reader.MakeDockerReader(client, reader.DockerOptions{
+			Containers: args,
+			Labels:     labels,
+			Since:      since,
+		}, nil)
+		if rules := multilineRules(cmd); rules != nil {
+			reader.Multiline(rules)
+		}
+		opts := append(appOptions(cmd), localFilterOption(cmd)...)
+		opts = append(opts, splitOption(cmd, nil)...)
+		app := loggo.NewLoggoApp(reader, templateFile, opts...)
+		app.Run()
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(dockerStreamCmd)
+	dockerStreamCmd.Flags().
+		StringArrayP("label", "l", nil,
+			`Only stream containers with this label, "key" or "key=value" (repeatable)`)
+	dockerStreamCmd.Flags().
+		DurationP("since", "", 0, "Only stream entries newer than this, e.g. 10m (default the whole log)")
+	dockerStreamCmd.Flags().
+		StringP("host", "", "",
+			`Docker Engine API, e.g. "unix:///var/run/docker.sock" or "tcp://127.0.0.1:2375"
+(default $DOCKER_HOST or `+docker.DefaultHost+`)`)
+	dockerStreamCmd.Flags().
+		StringP("template", "t", "", "Rendering Template")
+	addMultilineFlags(dockerStreamCmd)
+	addAppFlags(dockerStreamCmd)
+	addLocalFilterFlag(dockerStreamCmd)
+	addSplitFlags(dockerStreamCmd)
+}
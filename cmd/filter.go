@@ -0,0 +1,130 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/aurc/loggo/internal/config"
+	"github.com/aurc/loggo/internal/decoder"
+	"github.com/aurc/loggo/internal/filter"
+	"github.com/spf13/cobra"
+)
+
+// filterCmd represents the filter command
+var filterCmd = &cobra.Command{
+	Use:   "filter",
+	Short: "Filter log entries without the TUI",
+	Long: `Filter log entries from the standard input or files using the
+same filter expressions as the local filter of the stream commands,
+writing the matching lines to the standard output. For example:
+
+	loggo filter --expr "severity = 'ERROR' AND latency > 500" \
+	    --template t.yaml < in.jsonl > out.jsonl
+	loggo filter --expr "level = 'warn'" --file 'logs/*.log'
+
+If a template is provided, its key types (e.g. number, datetime) and
+parsers are honoured, otherwise keys are compared as strings. Lines the
+expression can't be evaluated against (e.g. a non numeric value for a
+number key) are skipped and counted on the standard error, unless
+--strict is set, in which case the first one fails the command.`,
+	SilenceUsage: true,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		expr := cmd.Flag("expr").Value.String()
+		templateFile := cmd.Flag("template").Value.String()
+		patterns, _ := cmd.Flags().GetStringArray("file")
+		strict, _ := cmd.Flags().GetBool("strict")
+		exp, err := filter.ParseFilterExpression(expr)
+		if err != nil {
+			return fmt.Errorf("invalid filter expression: %w", err)
+		}
+		cfg, err := config.MakeConfig(templateFile)
+		if err != nil {
+			return fmt.Errorf("unable to load template: %w", err)
+		}
+		dec, err := decoder.ForConfig(cfg)
+		if err != nil {
+			return err
+		}
+		keys := cfg.KeyMap()
+
+		if len(patterns) == 0 {
+			_, skipped, err := exp.Stream(os.Stdin, cmd.OutOrStdout(), keys, dec, strict)
+			reportSkipped(cmd.ErrOrStderr(), "stdin", skipped)
+			return err
+		}
+		for _, p := range patterns {
+			files, err := filepath.Glob(p)
+			if err != nil {
+				return err
+			}
+			if len(files) == 0 {
+				return fmt.Errorf("no files match %s", p)
+			}
+			for _, f := range files {
+				if err := filterFile(exp, f, cmd.OutOrStdout(), cmd.ErrOrStderr(), keys, dec, strict); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	},
+}
+
+func filterFile(exp *filter.Expression, fileName string, out, errOut io.Writer,
+	keys map[string]*config.Key, dec decoder.Chain, strict bool) error {
+	f, err := os.Open(fileName)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, skipped, err := exp.Stream(f, out, keys, dec, strict)
+	reportSkipped(errOut, fileName, skipped)
+	if err != nil {
+		return fmt.Errorf("%s: %w", fileName, err)
+	}
+	return nil
+}
+
+func reportSkipped(w io.Writer, source string, skipped int) {
+	if skipped > 0 {
+		_, _ = fmt.Fprintf(w, "%s: skipped %d line(s) the expression couldn't be evaluated against\n",
+			source, skipped)
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(filterCmd)
+	filterCmd.Flags().
+		StringP("expr", "e", "", "Filter expression (required)")
+	_ = filterCmd.MarkFlagRequired("expr")
+	filterCmd.Flags().
+		StringArrayP("file", "f", nil, "Input Log File or glob pattern (repeatable), defaults to stdin")
+	filterCmd.Flags().
+		StringP("template", "t", "", "Template whose key types and parsers are applied")
+	filterCmd.Flags().
+		Bool("strict", false, "Fail on the first line the expression can't be evaluated against instead of skipping it")
+}
@@ -109,7 +109,8 @@ from a given selected project and GCP logging filters:
 			}
 			time.Sleep(time.Second)
 			reader := reader.MakeGCPReader(projectName, filter, reader.ParseFrom(from), nil)
-			app := loggo.NewLoggoApp(reader, templateFile)
+			opts := append(appOptions(cmd), splitOption(cmd, nil)...)
+			app := loggo.NewLoggoApp(reader, templateFile, opts...)
 			app.Run()
 		}
 	},
@@ -157,4 +158,6 @@ if you may have a valid authentication file.`)
 			`Use the existing GCloud CLI infrastructure installed on your system for GCP
 authentication. You must have gcloud CLI installed and configured. If this 
 flag is not passed, it use l'oggo native connector.`)
+	addAppFlags(gcpStreamCmd)
+	addSplitFlags(gcpStreamCmd)
 }
@@ -0,0 +1,91 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package cmd
+
+import (
+	"github.com/aurc/loggo/internal/loggo"
+	"github.com/aurc/loggo/internal/reader"
+	"github.com/spf13/cobra"
+)
+
+// journalCmd represents the journal command
+var journalCmd = &cobra.Command{
+	Use:   "journal",
+	Short: "Continuously stream the systemd journal",
+	Long: `Continuously stream the systemd journal through journalctl,
+rendered with a ready-made template: timestamp, host, unit,
+severity (coloured by priority) and message. The template is
+installed under ~/.loggo/templates/journal.yaml the first time,
+so it can be customised:
+
+	loggo journal --unit api.service
+	loggo journal -u api.service -u nginx.service --since -1h
+	loggo journal --priority warning --filter "unit = 'api.service'"`,
+	Run: func(cmd *cobra.Command, args []string) {
+		templateFile := cmd.Flag("template").Value.String()
+		if len(templateFile) == 0 {
+			templateFile = builtinTemplate("journal")
+		}
+		units, _ := cmd.Flags().GetStringArray("unit")
+		user, _ := cmd.Flags().GetBool("user")
+		since, _ := cmd.Flags().GetString("since")
+		lines, _ := cmd.Flags().GetInt("lines")
+		priority, _ := cmd.Flags().GetString("priority")
+		directory, _ := cmd.Flags().GetString("directory")
+		reader := reader.MakeJournalReader(reader.JournalOptions{
+			Units:     units,
+			User:      user,
+			Since:     since,
+			Lines:     lines,
+			Priority:  priority,
+			Directory: directory,
+		}, nil)
+		opts := append(appOptions(cmd), localFilterOption(cmd)...)
+		opts = append(opts, splitOption(cmd, nil)...)
+		app := loggo.NewLoggoApp(reader, templateFile, opts...)
+		app.Run()
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(journalCmd)
+	journalCmd.Flags().
+		StringArrayP("unit", "u", nil, "Only stream the entries of this systemd unit (repeatable)")
+	journalCmd.Flags().
+		BoolP("user", "", false, "Stream the journal of the user's units")
+	journalCmd.Flags().
+		StringP("since", "S", "",
+			`Start streaming from, in any journalctl format, e.g. "-1h", "today" or
+"2024-01-02 10:00"`)
+	journalCmd.Flags().
+		IntP("lines", "n", 1000, "Number of the latest entries streamed first")
+	journalCmd.Flags().
+		StringP("priority", "p", "", `Only stream entries up to this priority, e.g. "warning" or "err"`)
+	journalCmd.Flags().
+		StringP("directory", "D", "", "Read the journal files of this directory instead of the system journal")
+	journalCmd.Flags().
+		StringP("template", "t", "", "Rendering Template (default the built-in journal template)")
+	addAppFlags(journalCmd)
+	addLocalFilterFlag(journalCmd)
+	addSplitFlags(journalCmd)
+}
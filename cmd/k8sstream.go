@@ -0,0 +1,103 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package cmd
+
+import (
+	"github.com/aurc/loggo/internal/k8s"
+	"github.com/aurc/loggo/internal/loggo"
+	"github.com/aurc/loggo/internal/reader"
+	"github.com/aurc/loggo/internal/util"
+	"github.com/spf13/cobra"
+)
+
+// k8sStreamCmd represents the k8s-stream command
+var k8sStreamCmd = &cobra.Command{
+	Use:   "k8s-stream",
+	Short: "Continuously stream Kubernetes pod logs",
+	Long: `Continuously stream the logs of the pods matching a namespace
+and label selector, straight from the Kubernetes API using your
+kubeconfig (as kubectl does). Pods created later, e.g. new
+replicas or restarted containers, are picked up as they start,
+and each entry is tagged with its 'pod', 'container' and
+'namespace':
+
+	loggo k8s-stream --namespace shop --selector app=checkout
+	loggo k8s-stream -n shop -l 'app in (cart,checkout)' -c app --since 10m
+	loggo k8s-stream --all-namespaces -l tier=backend --kube-context staging`,
+	Run: func(cmd *cobra.Command, args []string) {
+		templateFile := cmd.Flag("template").Value.String()
+		kubeconfig, _ := cmd.Flags().GetString("kubeconfig")
+		kubeContext, _ := cmd.Flags().GetString("kube-context")
+		client, namespace, err := k8s.Client(kubeconfig, kubeContext)
+		if err != nil {
+			util.Log().Fatal("Unable to load the kubeconfig: ", err)
+		}
+		if ns, _ := cmd.Flags().GetString("namespace"); len(ns) > 0 {
+			namespace = ns
+		}
+		if all, _ := cmd.Flags().GetBool("all-namespaces"); all {
+			namespace = ""
+		}
+		selector, _ := cmd.Flags().GetString("selector")
+		container, _ := cmd.Flags().GetString("container")
+		since, _ := cmd.Flags().GetDuration("since")
+		reader := reader.MakeK8sReader(client, reader.K8sOptions{
+			Namespace: namespace,
+			Selector:  selector,
+			Container: container,
+			Since:     since,
+		}, nil)
+		if rules := multilineRules(cmd); rules != nil {
+			reader.Multiline(rules)
+		}
+		opts := append(appOptions(cmd), localFilterOption(cmd)...)
+		opts = append(opts, splitOption(cmd, nil)...)
+		app := loggo.NewLoggoApp(reader, templateFile, opts...)
+		app.Run()
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(k8sStreamCmd)
+	k8sStreamCmd.Flags().
+		StringP("namespace", "n", "",
+			"Namespace of the pods (default the namespace of the kubeconfig context)")
+	k8sStreamCmd.Flags().
+		BoolP("all-namespaces", "A", false, "Stream pods of every namespace")
+	k8sStreamCmd.Flags().
+		StringP("selector", "l", "", "Label selector of the pods, e.g. app=checkout")
+	k8sStreamCmd.Flags().
+		StringP("container", "c", "", "Only stream the containers of this name")
+	k8sStreamCmd.Flags().
+		DurationP("since", "", 0, "Only stream entries newer than this, e.g. 10m (default the whole log)")
+	k8sStreamCmd.Flags().
+		StringP("kubeconfig", "", "", "Path to the kubeconfig file (default $KUBECONFIG or ~/.kube/config)")
+	k8sStreamCmd.Flags().
+		StringP("kube-context", "", "", "Kubeconfig context to use (default the current context)")
+	k8sStreamCmd.Flags().
+		StringP("template", "t", "", "Rendering Template")
+	addMultilineFlags(k8sStreamCmd)
+	addAppFlags(k8sStreamCmd)
+	addLocalFilterFlag(k8sStreamCmd)
+	addSplitFlags(k8sStreamCmd)
+}
@@ -0,0 +1,73 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package cmd
+
+import (
+	"github.com/aurc/loggo/internal/loggo"
+	"github.com/aurc/loggo/internal/reader"
+	"github.com/spf13/cobra"
+)
+
+// serveCmd represents the serve command
+var serveCmd = &cobra.Command{
+	Use:   "serve",
+	Short: "Receive log batches pushed over HTTP",
+	Long: `Run an HTTP server and stream the log batches pushed to it, so
+a service's log shipper can be pointed at loggo without files
+or pipes. The following endpoints are served (bodies may be
+gzip encoded):
+
+	POST /ingest                newline delimited json
+	POST /loki/api/v1/push      Loki push (json)
+	POST /_bulk, /{index}/_bulk Elasticsearch bulk
+
+Only the json flavour of the Loki push API is supported. promtail
+and Alloy push protobuf, which is answered 415: use a shipper that
+pushes json to Loki (e.g. Fluent Bit) or the other endpoints.
+
+It only listens on the loopback interface unless told otherwise,
+e.g. --listen :9999 to accept shippers on other hosts. For example:
+
+	loggo serve
+	curl -d '{"level":"info","msg":"hello"}' localhost:9999/ingest`,
+	Run: func(cmd *cobra.Command, args []string) {
+		templateFile := cmd.Flag("template").Value.String()
+		listen, _ := cmd.Flags().GetString("listen")
+		reader := reader.MakeHTTPReader(listen, nil)
+		opts := append(appOptions(cmd), localFilterOption(cmd)...)
+		opts = append(opts, splitOption(cmd, nil)...)
+		app := loggo.NewLoggoApp(reader, templateFile, opts...)
+		app.Run()
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(serveCmd)
+	serveCmd.Flags().
+		StringP("listen", "l", "127.0.0.1:9999", `Address to listen on, e.g. ":9999" for every interface`)
+	serveCmd.Flags().
+		StringP("template", "t", "", "Rendering Template")
+	addAppFlags(serveCmd)
+	addLocalFilterFlag(serveCmd)
+	addSplitFlags(serveCmd)
+}
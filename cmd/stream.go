@@ -23,8 +23,12 @@ THE SOFTWARE.
 package cmd
 
 import (
+	"path/filepath"
+
+	"github.com/aurc/loggo/internal/config"
 	"github.com/aurc/loggo/internal/loggo"
 	"github.com/aurc/loggo/internal/reader"
+	"github.com/aurc/loggo/internal/util"
 	"github.com/spf13/cobra"
 )
 
@@ -38,12 +42,54 @@ if it's reading from a file, it automatically detects file
 rotation and continue to stream. For example:
 
 	loggo stream --file <file-path>
-	<some arbitrary input> | loggo stream`,
+	<some arbitrary input> | loggo stream
+
+The file flag can be repeated and accepts glob patterns. Every
+matching file is streamed, files created later that match the
+pattern are picked up, and each entry is tagged with its source
+file under the '$_source' key:
+
+	loggo stream --file 'logs/app-*.log' --file other.log
+
+Multi-line records such as stack traces or pretty printed json
+can be assembled into a single entry:
+
+	loggo stream --file app.log --multiline-indent \
+	    --multiline-pattern '^Caused by:' --multiline-json
+
+The stream can start with a local filter applied, either inline
+or by the name of a filter saved from the filter bar:
+
+	loggo stream --file app.log --filter "level = 'error'"
+	loggo stream --file app.log --filter no-probes
+
+A second pane can show the same input with another filter, or
+other files altogether, optionally scrolling in sync by time:
+
+	loggo stream --file app.log --split-filter "level = 'error'"
+	loggo stream --file api.log --split-file worker.log --sync-scroll
+
+Several sources can be merged into a single stream ordered by
+the time of each entry, e.g. the logs of three pods during an
+incident. Each entry is tagged with its file under '$_source'
+and '-' stands for the standard input:
+
+	loggo stream --merge --file pod-a.log --file pod-b.log --file pod-c.log
+	kubectl logs -f api-0 | loggo stream --merge --file - --file api-1.log \
+	    --merge-key time --merge-window 5s`,
 	Run: func(cmd *cobra.Command, args []string) {
-		fileName := cmd.Flag("file").Value.String()
+		fileNames, _ := cmd.Flags().GetStringArray("file")
 		templateFile := cmd.Flag("template").Value.String()
-		reader := reader.MakeReader(fileName, nil)
-		app := loggo.NewLoggoApp(reader, templateFile)
+		reader := reader.MakeFilesReader(fileNames, nil)
+		if merge, _ := cmd.Flags().GetBool("merge"); merge {
+			reader = mergedReader(cmd, fileNames, templateFile)
+		}
+		if rules := multilineRules(cmd); rules != nil {
+			reader.Multiline(rules)
+		}
+		opts := append(appOptions(cmd), localFilterOption(cmd)...)
+		opts = append(opts, splitOption(cmd, splitReader(cmd))...)
+		app := loggo.NewLoggoApp(reader, templateFile, opts...)
 		app.Run()
 	},
 }
@@ -51,7 +97,112 @@ rotation and continue to stream. For example:
 func init() {
 	rootCmd.AddCommand(streamCmd)
 	streamCmd.Flags().
-		StringP("file", "f", "", "Input Log File")
+		StringArrayP("file", "f", nil, "Input Log File or glob pattern (repeatable)")
 	streamCmd.Flags().
 		StringP("template", "t", "", "Rendering Template")
+	streamCmd.Flags().
+		BoolP("merge", "", false,
+			`Merge the files (and the standard input given as '-') into a single
+stream ordered by the time of each entry`)
+	streamCmd.Flags().
+		StringP("merge-key", "", "",
+			`Key holding the time entries are merged by (default the first datetime
+key of the template, or timestamp/time/ts/@timestamp)`)
+	streamCmd.Flags().
+		DurationP("merge-window", "", reader.DefaultMergeWindow,
+			"How long entries are held back waiting for earlier ones from slower sources")
+	streamCmd.Flags().
+		StringArrayP("split-file", "", nil,
+			"Input Log File or glob pattern of the second pane (repeatable), see --split")
+	addMultilineFlags(streamCmd)
+	addAppFlags(streamCmd)
+	addLocalFilterFlag(streamCmd)
+	addSplitFlags(streamCmd)
+}
+
+// mergedReader merges every file matching the given patterns, each one being
+// a source of its own, and the standard input given as '-'.
+func mergedReader(cmd *cobra.Command, patterns []string, templateFile string) reader.Reader {
+	var sources []reader.MergeSource
+	for _, p := range patterns {
+		if p == "-" {
+			sources = append(sources, reader.MergeSource{Name: "stdin", Reader: reader.MakeReader("", nil)})
+			continue
+		}
+		files, err := filepath.Glob(p)
+		if err != nil {
+			util.Log().Fatal("Invalid --file pattern: ", err)
+		}
+		for _, f := range files {
+			sources = append(sources, reader.MergeSource{Name: f, Reader: reader.MakeReader(f, nil)})
+		}
+	}
+	if len(sources) == 0 {
+		util.Log().Fatal("No files to merge, use --file")
+	}
+	window, _ := cmd.Flags().GetDuration("merge-window")
+	return reader.MakeMergedReader(sources, mergeKey(cmd, templateFile), window, nil)
+}
+
+// mergeKey resolves the key entries are merged by, taking its layout from the
+// template when it's there.
+func mergeKey(cmd *cobra.Command, templateFile string) *config.Key {
+	name, _ := cmd.Flags().GetString("merge-key")
+	var keys []config.Key
+	if len(templateFile) > 0 {
+		c, err := config.MakeConfig(templateFile)
+		if err != nil {
+			util.Log().Fatal("Unable to read the template: ", err)
+		}
+		keys = c.Keys
+	}
+	for i := range keys {
+		if (len(name) == 0 && keys[i].Type == config.TypeDateTime) || keys[i].Name == name {
+			return &keys[i]
+		}
+	}
+	if len(name) == 0 {
+		return nil
+	}
+	return &config.Key{Name: name, Type: config.TypeDateTime}
+}
+
+// splitReader streams the files of the second pane, if any.
+func splitReader(cmd *cobra.Command) reader.Reader {
+	fileNames, _ := cmd.Flags().GetStringArray("split-file")
+	if len(fileNames) == 0 {
+		return nil
+	}
+	r := reader.MakeFilesReader(fileNames, nil)
+	if rules := multilineRules(cmd); rules != nil {
+		r.Multiline(rules)
+	}
+	return r
+}
+
+// addMultilineFlags registers the flags read by multilineRules.
+func addMultilineFlags(cmd *cobra.Command) {
+	cmd.Flags().
+		BoolP("multiline-indent", "", false,
+			"Append lines starting with a space or tab to the previous entry (e.g. stack traces)")
+	cmd.Flags().
+		StringP("multiline-pattern", "", "",
+			"Append lines matching this regular expression to the previous entry")
+	cmd.Flags().
+		BoolP("multiline-json", "", false,
+			"Assemble json objects spanning several lines (pretty printed) into a single entry")
+}
+
+func multilineRules(cmd *cobra.Command) *reader.MultilineRules {
+	indent, _ := cmd.Flags().GetBool("multiline-indent")
+	pattern, _ := cmd.Flags().GetString("multiline-pattern")
+	jsonObj, _ := cmd.Flags().GetBool("multiline-json")
+	if !indent && !jsonObj && len(pattern) == 0 {
+		return nil
+	}
+	return &reader.MultilineRules{
+		Continuation: pattern,
+		Indented:     indent,
+		JSON:         jsonObj,
+	}
 }
@@ -0,0 +1,92 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package cmd
+
+import (
+	"github.com/aurc/loggo/internal/config"
+	"github.com/aurc/loggo/internal/loggo"
+	"github.com/aurc/loggo/internal/reader"
+	"github.com/aurc/loggo/internal/util"
+	"github.com/spf13/cobra"
+)
+
+// syslogCmd represents the syslog command
+var syslogCmd = &cobra.Command{
+	Use:   "syslog",
+	Short: "Receive syslog messages over UDP or TCP",
+	Long: `Run a syslog server and stream the messages it receives, in
+RFC 5424 (including structured data, under the 'sd' key) or
+RFC 3164 (BSD) format. Over TCP, messages are either newline
+delimited or octet counted. Messages are rendered with a
+ready-made template installed under ~/.loggo/templates/syslog.yaml
+the first time, so it can be customised. It only listens on the
+loopback interface unless told otherwise, e.g. --listen udp://:5514
+to receive messages from other hosts:
+
+	loggo syslog
+	loggo syslog --listen tcp://127.0.0.1:5514 --filter "severity IN ('ERROR', 'CRIT')"
+
+Test it with a loopback client, e.g.:
+
+	logger --server 127.0.0.1 --port 5514 --udp --rfc5424 "hello"`,
+	Run: func(cmd *cobra.Command, args []string) {
+		templateFile := cmd.Flag("template").Value.String()
+		if len(templateFile) == 0 {
+			templateFile = builtinTemplate("syslog")
+		}
+		listen, _ := cmd.Flags().GetString("listen")
+		if _, _, err := reader.ParseListen(listen); err != nil {
+			util.Log().Fatal(err)
+		}
+		reader := reader.MakeSyslogReader(listen, nil)
+		opts := append(appOptions(cmd), localFilterOption(cmd)...)
+		opts = append(opts, splitOption(cmd, nil)...)
+		app := loggo.NewLoggoApp(reader, templateFile, opts...)
+		app.Run()
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(syslogCmd)
+	syslogCmd.Flags().
+		StringP("listen", "l", "udp://127.0.0.1:5514", `Address to listen on, "udp://host:port" or "tcp://host:port"`)
+	syslogCmd.Flags().
+		StringP("template", "t", "", "Rendering Template (default the built-in syslog template)")
+	addAppFlags(syslogCmd)
+	addLocalFilterFlag(syslogCmd)
+	addSplitFlags(syslogCmd)
+}
+
+// builtinTemplate installs the built-in template of the given name, if not
+// already, and returns its file.
+func builtinTemplate(name string) string {
+	dir, err := config.TemplatesDir()
+	if err == nil {
+		var fileName string
+		if fileName, err = config.InstallTemplate(dir, name); err == nil {
+			return fileName
+		}
+	}
+	util.Log().Fatalf("Unable to install the %s template: %v", name, err)
+	return ""
+}
@@ -0,0 +1,45 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package aggregate
+
+import "strings"
+
+var sparks = []rune("▁▂▃▄▅▆▇█")
+
+// Sparkline draws the series as a line of block characters scaled to its
+// highest value. Empty buckets are left blank.
+func Sparkline(series []int) string {
+	peak := 0
+	for _, n := range series {
+		peak = max(peak, n)
+	}
+	var sb strings.Builder
+	for _, n := range series {
+		if n == 0 {
+			sb.WriteRune(' ')
+			continue
+		}
+		sb.WriteRune(sparks[(n*len(sparks)-1)/peak])
+	}
+	return sb.String()
+}
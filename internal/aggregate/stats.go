@@ -0,0 +1,109 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package aggregate
+
+import (
+	"iter"
+	"math"
+	"slices"
+	"strconv"
+
+	"github.com/aurc/loggo/internal/config"
+)
+
+// Bin is a range of a distribution, From inclusive, To exclusive except for
+// the last bin.
+type Bin struct {
+	From, To float64
+	Count    int
+}
+
+// NumericStats summarises the values of a number key.
+type NumericStats struct {
+	Key   string
+	Count int
+	// Invalid counts the entries where the key is missing or not a number.
+	Invalid        int
+	Min, Max, Mean float64
+	P50, P90, P99  float64
+	Distribution   []Bin
+}
+
+// Stats computes the statistics of the numeric values of key across entries,
+// with their distribution over the given number of bins.
+func Stats(key config.Key, entries iter.Seq[map[string]any], bins int) NumericStats {
+	st := NumericStats{Key: key.Name}
+	var values []float64
+	sum := 0.0
+	for m := range entries {
+		n, err := strconv.ParseFloat(key.ExtractValue(m), 64)
+		if err != nil || math.IsNaN(n) || math.IsInf(n, 0) {
+			st.Invalid++
+			continue
+		}
+		values = append(values, n)
+		sum += n
+	}
+	st.Count = len(values)
+	if st.Count == 0 {
+		return st
+	}
+	slices.Sort(values)
+	st.Min, st.Max = values[0], values[len(values)-1]
+	st.Mean = sum / float64(st.Count)
+	st.P50 = percentile(values, 50)
+	st.P90 = percentile(values, 90)
+	st.P99 = percentile(values, 99)
+	st.Distribution = distribution(values, bins)
+	return st
+}
+
+// percentile interpolates linearly between the closest ranks of the sorted
+// values.
+func percentile(sorted []float64, p float64) float64 {
+	rank := p / 100 * float64(len(sorted)-1)
+	lo := int(math.Floor(rank))
+	hi := int(math.Ceil(rank))
+	return sorted[lo] + (sorted[hi]-sorted[lo])*(rank-float64(lo))
+}
+
+func distribution(sorted []float64, bins int) []Bin {
+	if bins <= 0 {
+		return nil
+	}
+	lo, hi := sorted[0], sorted[len(sorted)-1]
+	if lo == hi {
+		return []Bin{{From: lo, To: hi, Count: len(sorted)}}
+	}
+	width := (hi - lo) / float64(bins)
+	dist := make([]Bin, bins)
+	for i := range dist {
+		dist[i].From = lo + width*float64(i)
+		dist[i].To = lo + width*float64(i+1)
+	}
+	dist[bins-1].To = hi
+	for _, v := range sorted {
+		dist[min(int((v-lo)/width), bins-1)].Count++
+	}
+	return dist
+}
@@ -0,0 +1,69 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package aggregate
+
+import (
+	"testing"
+
+	"github.com/aurc/loggo/internal/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStats(t *testing.T) {
+	key := config.Key{Name: "latency_ms", Type: config.TypeNumber}
+	var entries []map[string]any
+	for i := 1; i <= 100; i++ {
+		entries = append(entries, map[string]any{"latency_ms": float64(i)})
+	}
+	entries = append(entries, map[string]any{"latency_ms": "slow"}, map[string]any{})
+
+	got := Stats(key, rows(entries...), 4)
+	assert.Equal(t, "latency_ms", got.Key)
+	assert.Equal(t, 100, got.Count)
+	assert.Equal(t, 2, got.Invalid)
+	assert.Equal(t, 1.0, got.Min)
+	assert.Equal(t, 100.0, got.Max)
+	assert.Equal(t, 50.5, got.Mean)
+	assert.InDelta(t, 50.5, got.P50, 1e-9)
+	assert.InDelta(t, 90.1, got.P90, 1e-9)
+	assert.InDelta(t, 99.01, got.P99, 1e-9)
+	assert.Equal(t, []Bin{
+		{From: 1, To: 25.75, Count: 25},
+		{From: 25.75, To: 50.5, Count: 25},
+		{From: 50.5, To: 75.25, Count: 25},
+		{From: 75.25, To: 100, Count: 25},
+	}, got.Distribution)
+}
+
+func TestStats_Degenerate(t *testing.T) {
+	key := config.Key{Name: "n", Type: config.TypeNumber}
+
+	got := Stats(key, rows(map[string]any{"n": "x"}), 4)
+	assert.Equal(t, 0, got.Count)
+	assert.Equal(t, 1, got.Invalid)
+	assert.Nil(t, got.Distribution)
+
+	got = Stats(key, rows(map[string]any{"n": 7}, map[string]any{"n": 7}), 4)
+	assert.Equal(t, 7.0, got.P99)
+	assert.Equal(t, []Bin{{From: 7, To: 7, Count: 2}}, got.Distribution)
+}
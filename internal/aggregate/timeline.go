@@ -0,0 +1,92 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package aggregate
+
+import "time"
+
+// Point is an entry placed on a timeline, in one of several series (e.g. the
+// severity of the entry).
+type Point struct {
+	At     time.Time
+	Series int
+}
+
+// Bucket is a time range of a timeline.
+type Bucket struct {
+	From, To time.Time
+	// Counts holds the number of points in each series.
+	Counts []int
+	Total  int
+	// First is the index of the first point falling in the bucket, -1 if
+	// the bucket is empty.
+	First int
+}
+
+// Timeline spreads points over the given number of equally sized buckets
+// spanning from the earliest to the latest point. Points with a zero time are
+// left out.
+func Timeline(points []Point, series, buckets int) []Bucket {
+	if buckets <= 0 {
+		return nil
+	}
+	var from, to time.Time
+	for _, p := range points {
+		if p.At.IsZero() {
+			continue
+		}
+		if from.IsZero() || p.At.Before(from) {
+			from = p.At
+		}
+		if to.IsZero() || p.At.After(to) {
+			to = p.At
+		}
+	}
+	if from.IsZero() {
+		return nil
+	}
+	// the latest point must fall in the last bucket
+	width := to.Sub(from)/time.Duration(buckets) + 1
+	tl := make([]Bucket, buckets)
+	for i := range tl {
+		tl[i] = Bucket{
+			From:   from.Add(width * time.Duration(i)),
+			To:     from.Add(width * time.Duration(i+1)),
+			Counts: make([]int, series),
+			First:  -1,
+		}
+	}
+	for i, p := range points {
+		if p.At.IsZero() {
+			continue
+		}
+		b := &tl[min(int(p.At.Sub(from)/width), buckets-1)]
+		if p.Series >= 0 && p.Series < series {
+			b.Counts[p.Series]++
+		}
+		b.Total++
+		if b.First < 0 {
+			b.First = i
+		}
+	}
+	return tl
+}
@@ -0,0 +1,92 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package aggregate
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTimeline(t *testing.T) {
+	start := time.Date(2023, 1, 2, 10, 0, 0, 0, time.UTC)
+	at := func(secs int) time.Time {
+		return start.Add(time.Duration(secs) * time.Second)
+	}
+	tests := []struct {
+		name       string
+		points     []Point
+		buckets    int
+		wantCounts [][]int
+		wantFirst  []int
+	}{
+		{
+			name:    "No points",
+			buckets: 3,
+		},
+		{
+			name: "Spread and stacked by series",
+			points: []Point{
+				{At: at(0), Series: 0},
+				{At: at(5), Series: 1},
+				{At: at(1), Series: 1},
+				{},
+				{At: at(9), Series: 0},
+			},
+			buckets:    3,
+			wantCounts: [][]int{{1, 1}, {0, 1}, {1, 0}},
+			wantFirst:  []int{0, 1, 4},
+		},
+		{
+			name: "Same time",
+			points: []Point{
+				{At: at(3), Series: 1},
+				{At: at(3), Series: 1},
+			},
+			buckets:    2,
+			wantCounts: [][]int{{0, 2}, {0, 0}},
+			wantFirst:  []int{0, -1},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := Timeline(test.points, 2, test.buckets)
+			if test.wantCounts == nil {
+				assert.Nil(t, got)
+				return
+			}
+			assert.Len(t, got, test.buckets)
+			var counts [][]int
+			var first []int
+			for _, b := range got {
+				counts = append(counts, b.Counts)
+				first = append(first, b.First)
+				assert.Equal(t, b.Counts[0]+b.Counts[1], b.Total)
+			}
+			assert.Equal(t, test.wantCounts, counts)
+			assert.Equal(t, test.wantFirst, first)
+			assert.Equal(t, got[0].From, test.points[0].At)
+		})
+	}
+}
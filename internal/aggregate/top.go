@@ -0,0 +1,163 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package aggregate
+
+import (
+	"cmp"
+	"iter"
+	"slices"
+	"time"
+
+	"github.com/aurc/loggo/internal/config"
+)
+
+// Count is how often a value of a key was seen.
+type Count struct {
+	Value   string
+	Count   int
+	Percent float64
+	// Series is the number of occurrences over time, oldest bucket first.
+	Series []int
+}
+
+// TopValues summarises the values of a key, most frequent first.
+type TopValues struct {
+	Key    string
+	Total  int
+	Values []Count
+	// Missing counts the entries without the key.
+	Missing Count
+	// Others counts the entries whose value didn't make it to the top.
+	Others Count
+}
+
+type observation struct {
+	value string
+	at    time.Time
+	timed bool
+}
+
+// Top counts the values of key across entries, keeping the limit most
+// frequent. Occurrences are spread over the given number of buckets by the
+// timeKey of each entry; when there's no timeKey or none of the entries has a
+// valid time, they are spread by their position instead.
+func Top(key config.Key, timeKey *config.Key, entries iter.Seq[map[string]any], limit, buckets int) TopValues {
+	var obs []observation
+	for m := range entries {
+		o := observation{value: key.ExtractValue(m)}
+		if timeKey != nil {
+			o.at, o.timed = timeKey.ExtractTime(m)
+		}
+		obs = append(obs, o)
+	}
+	bucket := bucketer(obs, buckets)
+
+	top := TopValues{
+		Key:     key.Name,
+		Total:   len(obs),
+		Missing: Count{Series: make([]int, buckets)},
+		Others:  Count{Series: make([]int, buckets)},
+	}
+	counts := make(map[string]*Count)
+	for i, o := range obs {
+		c := &top.Missing
+		if len(o.value) > 0 {
+			if c = counts[o.value]; c == nil {
+				c = &Count{Value: o.value, Series: make([]int, buckets)}
+				counts[o.value] = c
+			}
+		}
+		c.Count++
+		if b := bucket(i); b >= 0 {
+			c.Series[b]++
+		}
+	}
+	for _, c := range counts {
+		top.Values = append(top.Values, *c)
+	}
+	slices.SortFunc(top.Values, func(a, b Count) int {
+		if c := cmp.Compare(b.Count, a.Count); c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Value, b.Value)
+	})
+	if limit > 0 && len(top.Values) > limit {
+		for _, c := range top.Values[limit:] {
+			top.Others.Count += c.Count
+			for i, n := range c.Series {
+				top.Others.Series[i] += n
+			}
+		}
+		top.Values = top.Values[:limit]
+	}
+	for i := range top.Values {
+		top.Values[i].Percent = percent(top.Values[i].Count, top.Total)
+	}
+	top.Missing.Percent = percent(top.Missing.Count, top.Total)
+	top.Others.Percent = percent(top.Others.Count, top.Total)
+	return top
+}
+
+// bucketer returns the bucket each observation falls into, -1 for entries
+// without a time when spreading by time.
+func bucketer(obs []observation, buckets int) func(i int) int {
+	var from, to time.Time
+	timed := false
+	for _, o := range obs {
+		if !o.timed {
+			continue
+		}
+		if !timed || o.at.Before(from) {
+			from = o.at
+		}
+		if !timed || o.at.After(to) {
+			to = o.at
+		}
+		timed = true
+	}
+	if buckets <= 0 {
+		return func(int) int { return -1 }
+	}
+	if !timed {
+		return func(i int) int {
+			return i * buckets / len(obs)
+		}
+	}
+	span := to.Sub(from)
+	return func(i int) int {
+		if !obs[i].timed {
+			return -1
+		}
+		if span == 0 {
+			return buckets - 1
+		}
+		return min(int(float64(obs[i].at.Sub(from))/float64(span)*float64(buckets)), buckets-1)
+	}
+}
+
+func percent(n, total int) float64 {
+	if total == 0 {
+		return 0
+	}
+	return float64(n) * 100 / float64(total)
+}
@@ -0,0 +1,106 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package aggregate
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/aurc/loggo/internal/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func rows(r ...map[string]any) func(func(map[string]any) bool) {
+	return slices.Values(r)
+}
+
+func TestTop(t *testing.T) {
+	level := config.Key{Name: "level"}
+	ts := &config.Key{Name: "ts", Type: config.TypeDateTime}
+	tests := []struct {
+		name        string
+		timeKey     *config.Key
+		entries     []map[string]any
+		limit       int
+		wantValues  []Count
+		wantMissing Count
+		wantOthers  Count
+	}{
+		{
+			name: "Counted and spread by position",
+			entries: []map[string]any{
+				{"level": "info"}, {"level": "error"}, {"level": "info"}, {},
+			},
+			wantValues: []Count{
+				{Value: "info", Count: 2, Percent: 50, Series: []int{1, 1}},
+				{Value: "error", Count: 1, Percent: 25, Series: []int{1, 0}},
+			},
+			wantMissing: Count{Count: 1, Percent: 25, Series: []int{0, 1}},
+			wantOthers:  Count{Series: []int{0, 0}},
+		},
+		{
+			name:    "Spread by time",
+			timeKey: ts,
+			entries: []map[string]any{
+				{"level": "warn", "ts": "2023-01-02T10:00:00Z"},
+				{"level": "warn", "ts": "2023-01-02T10:00:01Z"},
+				{"level": "warn", "ts": "2023-01-02T10:00:10Z"},
+				{"level": "warn", "ts": "garbage"},
+			},
+			wantValues: []Count{
+				{Value: "warn", Count: 4, Percent: 100, Series: []int{2, 1}},
+			},
+			wantMissing: Count{Series: []int{0, 0}},
+			wantOthers:  Count{Series: []int{0, 0}},
+		},
+		{
+			name: "Least frequent beyond the limit are grouped",
+			entries: []map[string]any{
+				{"level": "b"}, {"level": "a"}, {"level": "c"}, {"level": "a"},
+			},
+			limit: 2,
+			wantValues: []Count{
+				{Value: "a", Count: 2, Percent: 50, Series: []int{1, 1}},
+				{Value: "b", Count: 1, Percent: 25, Series: []int{1, 0}},
+			},
+			wantMissing: Count{Series: []int{0, 0}},
+			wantOthers:  Count{Count: 1, Percent: 25, Series: []int{0, 1}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := Top(level, test.timeKey, rows(test.entries...), test.limit, 2)
+			assert.Equal(t, "level", got.Key)
+			assert.Equal(t, len(test.entries), got.Total)
+			assert.Equal(t, test.wantValues, got.Values)
+			assert.Equal(t, test.wantMissing, got.Missing)
+			assert.Equal(t, test.wantOthers, got.Others)
+		})
+	}
+}
+
+func TestSparkline(t *testing.T) {
+	assert.Equal(t, "", Sparkline(nil))
+	assert.Equal(t, "  ", Sparkline([]int{0, 0}))
+	assert.Equal(t, "▁▄ █", Sparkline([]int{1, 4, 0, 8}))
+}
@@ -0,0 +1,368 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package buffer
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// entryOverhead roughly accounts for the cost of holding a decoded entry
+// (maps, interfaces and strings) relative to its raw size.
+const entryOverhead = 3
+
+const spillCacheSize = 512
+
+// arrivalResolution is how often the ingestion time of appended entries is
+// recorded. Entries appended in between share the last recorded time.
+const arrivalResolution = 100 * time.Millisecond
+
+var timeNow = time.Now
+
+// Options sets the retention policy of a Buffer. Zero values mean unbounded.
+type Options struct {
+	// MaxEntries is the maximum number of entries held in memory.
+	MaxEntries int
+	// MaxMemory is the approximate maximum number of bytes held in memory.
+	MaxMemory int64
+	// Spill moves entries evicted from memory to a temporary file on disk, so
+	// they can still be browsed and filtered, instead of dropping them.
+	Spill bool
+}
+
+type entry struct {
+	value map[string]any
+	size  int64
+}
+
+// arrival records the time the entry with the sequence number seq, and the
+// ones following it, were appended.
+type arrival struct {
+	seq int64
+	at  time.Time
+}
+
+// Buffer holds the streamed entries in a ring buffer addressed by their
+// ingestion sequence number. Once the retention limits are reached the oldest
+// entries are evicted, or spilled to disk if enabled. It's safe for concurrent
+// use.
+type Buffer struct {
+	opts     Options
+	lock     sync.Mutex
+	ring     []entry
+	head     int
+	count    int
+	memory   int64
+	next     int64
+	evicted  int64
+	spill    *segment
+	err      error
+	arrivals []arrival
+}
+
+// New builds an empty buffer with the given retention policy.
+func New(opts Options) *Buffer {
+	return &Buffer{
+		opts: opts,
+		ring: make([]entry, 16),
+	}
+}
+
+// Append adds an entry whose raw (undecoded) size is size, returning its
+// sequence number.
+func (b *Buffer) Append(value map[string]any, size int) int64 {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if b.count == len(b.ring) {
+		b.grow()
+	}
+	e := entry{value: value, size: int64(size) * entryOverhead}
+	b.ring[(b.head+b.count)%len(b.ring)] = e
+	b.count++
+	b.memory += e.size
+	seq := b.next
+	b.next++
+	for b.count > 1 && b.exceeded() {
+		b.evictOldest()
+	}
+	b.recordArrival(seq)
+	return seq
+}
+
+func (b *Buffer) recordArrival(seq int64) {
+	now := timeNow()
+	if n := len(b.arrivals); n == 0 || now.Sub(b.arrivals[n-1].at) >= arrivalResolution {
+		b.arrivals = append(b.arrivals, arrival{seq: seq, at: now})
+	}
+	// keep only the record covering the oldest available entry onwards
+	first := b.first()
+	i := 0
+	for i+1 < len(b.arrivals) && b.arrivals[i+1].seq <= first {
+		i++
+	}
+	if i > 0 {
+		b.arrivals = append(b.arrivals[:0], b.arrivals[i:]...)
+	}
+}
+
+// ArrivedAt returns approximately when the entry with the given sequence
+// number was appended, or false if it was evicted or doesn't exist yet.
+func (b *Buffer) ArrivedAt(seq int64) (time.Time, bool) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if seq < b.first() || seq >= b.next || len(b.arrivals) == 0 {
+		return time.Time{}, false
+	}
+	i := sort.Search(len(b.arrivals), func(i int) bool {
+		return b.arrivals[i].seq > seq
+	})
+	if i == 0 {
+		return time.Time{}, false
+	}
+	return b.arrivals[i-1].at, true
+}
+
+func (b *Buffer) exceeded() bool {
+	return b.opts.MaxEntries > 0 && b.count > b.opts.MaxEntries ||
+		b.opts.MaxMemory > 0 && b.memory > b.opts.MaxMemory
+}
+
+func (b *Buffer) grow() {
+	size := len(b.ring) * 2
+	if b.opts.MaxEntries > 0 && size > b.opts.MaxEntries+1 {
+		size = b.opts.MaxEntries + 1
+	}
+	ring := make([]entry, size)
+	for i := 0; i < b.count; i++ {
+		ring[i] = b.ring[(b.head+i)%len(b.ring)]
+	}
+	b.ring = ring
+	b.head = 0
+}
+
+func (b *Buffer) evictOldest() {
+	e := b.ring[b.head]
+	b.ring[b.head] = entry{}
+	b.head = (b.head + 1) % len(b.ring)
+	b.count--
+	b.memory -= e.size
+	if b.opts.Spill && b.err == nil {
+		if b.spill == nil {
+			b.spill, b.err = newSegment()
+		}
+		if b.err == nil {
+			b.err = b.spill.write(e.value)
+		}
+		if b.err == nil {
+			return
+		}
+		// Spilling failed, drop everything on disk and carry on in memory.
+		if b.spill != nil {
+			b.evicted += int64(len(b.spill.offsets))
+			_ = b.spill.close()
+			b.spill = nil
+		}
+	}
+	b.evicted++
+}
+
+// Get returns the entry with the given sequence number, or false if it was
+// evicted or doesn't exist yet.
+func (b *Buffer) Get(seq int64) (map[string]any, bool) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if seq < b.first() || seq >= b.next {
+		return nil, false
+	}
+	memFirst := b.next - int64(b.count)
+	if seq >= memFirst {
+		return b.ring[(b.head+int(seq-memFirst))%len(b.ring)].value, true
+	}
+	if b.spill == nil {
+		return nil, false
+	}
+	m, err := b.spill.read(int(seq - b.evicted))
+	if err != nil {
+		return nil, false
+	}
+	return m, true
+}
+
+// First returns the sequence number of the oldest entry still available.
+func (b *Buffer) First() int64 {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.first()
+}
+
+func (b *Buffer) first() int64 {
+	return b.evicted
+}
+
+// Next returns the sequence number the next appended entry will get, which is
+// also the total number of entries ever appended.
+func (b *Buffer) Next() int64 {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.next
+}
+
+// Evicted returns how many entries were dropped by the retention policy.
+func (b *Buffer) Evicted() int64 {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.evicted
+}
+
+// Spilled returns how many entries are held on disk.
+func (b *Buffer) Spilled() int64 {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if b.spill == nil {
+		return 0
+	}
+	return int64(len(b.spill.offsets))
+}
+
+// Err returns the error that disabled spilling, if any.
+func (b *Buffer) Err() error {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	return b.err
+}
+
+// Close releases the buffer, removing any spill file.
+func (b *Buffer) Close() error {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	b.ring = make([]entry, 16)
+	b.head, b.count, b.memory = 0, 0, 0
+	b.arrivals = nil
+	if b.spill != nil {
+		err := b.spill.close()
+		b.spill = nil
+		return err
+	}
+	return nil
+}
+
+// segment is an append only jsonl file holding spilled entries.
+type segment struct {
+	file    *os.File
+	offsets []int64
+	end     int64
+	cache   map[int]map[string]any
+	order   []int
+}
+
+func newSegment() (*segment, error) {
+	f, err := os.CreateTemp("", "loggo-spill-*.jsonl")
+	if err != nil {
+		return nil, err
+	}
+	return &segment{
+		file:  f,
+		cache: make(map[int]map[string]any),
+	}, nil
+}
+
+func (s *segment) write(m map[string]any) error {
+	b, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	b = append(b, '\n')
+	if _, err := s.file.WriteAt(b, s.end); err != nil {
+		return err
+	}
+	s.offsets = append(s.offsets, s.end)
+	s.end += int64(len(b))
+	return nil
+}
+
+func (s *segment) read(i int) (map[string]any, error) {
+	if m, ok := s.cache[i]; ok {
+		return m, nil
+	}
+	end := s.end
+	if i+1 < len(s.offsets) {
+		end = s.offsets[i+1]
+	}
+	b := make([]byte, end-s.offsets[i])
+	if _, err := s.file.ReadAt(b, s.offsets[i]); err != nil {
+		return nil, err
+	}
+	m := make(map[string]any)
+	if err := json.Unmarshal(b, &m); err != nil {
+		return nil, err
+	}
+	if len(s.order) == spillCacheSize {
+		delete(s.cache, s.order[0])
+		s.order = s.order[1:]
+	}
+	s.cache[i] = m
+	s.order = append(s.order, i)
+	return m, nil
+}
+
+func (s *segment) close() error {
+	name := s.file.Name()
+	err := s.file.Close()
+	if rErr := os.Remove(name); err == nil {
+		err = rErr
+	}
+	return err
+}
+
+// ParseSize parses a human readable size such as 512KB, 200MB or 1GB into
+// bytes. A plain number is taken as bytes.
+func ParseSize(size string) (int64, error) {
+	s := strings.ToUpper(strings.TrimSpace(size))
+	if len(s) == 0 {
+		return 0, nil
+	}
+	multiplier := int64(1)
+	for _, u := range []struct {
+		suffix string
+		value  int64
+	}{
+		{"KB", 1 << 10}, {"MB", 1 << 20}, {"GB", 1 << 30}, {"K", 1 << 10}, {"M", 1 << 20}, {"G", 1 << 30}, {"B", 1},
+	} {
+		if strings.HasSuffix(s, u.suffix) {
+			multiplier = u.value
+			s = strings.TrimSpace(strings.TrimSuffix(s, u.suffix))
+			break
+		}
+	}
+	n, err := strconv.ParseFloat(s, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid size %q", size)
+	}
+	return int64(n * float64(multiplier)), nil
+}
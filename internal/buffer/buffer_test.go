@@ -0,0 +1,170 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package buffer
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuffer_Append(t *testing.T) {
+	tests := []struct {
+		name         string
+		givenOptions Options
+		givenEntries int
+		wantsFirst   int64
+		wantsEvicted int64
+		wantsSpilled int64
+	}{
+		{
+			name:         "unbounded",
+			givenEntries: 100,
+		},
+		{
+			name:         "max entries",
+			givenOptions: Options{MaxEntries: 10},
+			givenEntries: 100,
+			wantsFirst:   90,
+			wantsEvicted: 90,
+		},
+		{
+			name:         "max memory",
+			givenOptions: Options{MaxMemory: 10 * 10 * entryOverhead},
+			givenEntries: 100,
+			wantsFirst:   90,
+			wantsEvicted: 90,
+		},
+		{
+			name:         "max entries with spill",
+			givenOptions: Options{MaxEntries: 10, Spill: true},
+			givenEntries: 100,
+			wantsSpilled: 90,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			b := New(test.givenOptions)
+			defer b.Close()
+			for i := 0; i < test.givenEntries; i++ {
+				seq := b.Append(map[string]any{"i": fmt.Sprint(i)}, 10)
+				assert.Equal(t, int64(i), seq)
+			}
+			assert.Equal(t, test.wantsFirst, b.First())
+			assert.Equal(t, int64(test.givenEntries), b.Next())
+			assert.Equal(t, test.wantsEvicted, b.Evicted())
+			assert.Equal(t, test.wantsSpilled, b.Spilled())
+			assert.NoError(t, b.Err())
+
+			if test.wantsFirst > 0 {
+				_, ok := b.Get(test.wantsFirst - 1)
+				assert.False(t, ok)
+			}
+			for i := test.wantsFirst; i < b.Next(); i++ {
+				m, ok := b.Get(i)
+				assert.True(t, ok)
+				assert.Equal(t, fmt.Sprint(i), m["i"])
+			}
+			_, ok := b.Get(b.Next())
+			assert.False(t, ok)
+		})
+	}
+}
+
+func TestBuffer_Close(t *testing.T) {
+	b := New(Options{MaxEntries: 1, Spill: true})
+	b.Append(map[string]any{"a": "1"}, 1)
+	b.Append(map[string]any{"a": "2"}, 1)
+	assert.Equal(t, int64(1), b.Spilled())
+	name := b.spill.file.Name()
+	assert.FileExists(t, name)
+	assert.NoError(t, b.Close())
+	assert.NoFileExists(t, name)
+	_, ok := b.Get(0)
+	assert.False(t, ok)
+}
+
+func TestBuffer_ArrivedAt(t *testing.T) {
+	defer func(f func() time.Time) { timeNow = f }(timeNow)
+	start := time.Date(2023, 1, 2, 10, 0, 0, 0, time.UTC)
+	now := start
+	timeNow = func() time.Time { return now }
+
+	b := New(Options{MaxEntries: 4})
+	defer b.Close()
+	b.Append(map[string]any{}, 1) // 0
+	now = now.Add(arrivalResolution / 2)
+	b.Append(map[string]any{}, 1) // 1, same record as 0
+	now = now.Add(time.Second)
+	b.Append(map[string]any{}, 1) // 2
+	now = now.Add(time.Second)
+	b.Append(map[string]any{}, 1) // 3
+
+	at, ok := b.ArrivedAt(1)
+	assert.True(t, ok)
+	assert.Equal(t, start, at)
+	at, ok = b.ArrivedAt(3)
+	assert.True(t, ok)
+	assert.Equal(t, start.Add(arrivalResolution/2+2*time.Second), at)
+	_, ok = b.ArrivedAt(4)
+	assert.False(t, ok)
+
+	now = now.Add(time.Second)
+	b.Append(map[string]any{}, 1) // 4, evicts 0
+	b.Append(map[string]any{}, 1) // 5, evicts 1
+	_, ok = b.ArrivedAt(1)
+	assert.False(t, ok)
+	at, ok = b.ArrivedAt(2)
+	assert.True(t, ok)
+	assert.Equal(t, start.Add(arrivalResolution/2+time.Second), at)
+	assert.Len(t, b.arrivals, 3)
+}
+
+func TestParseSize(t *testing.T) {
+	tests := []struct {
+		given      string
+		wants      int64
+		wantsError bool
+	}{
+		{given: "", wants: 0},
+		{given: "1024", wants: 1024},
+		{given: "512KB", wants: 512 << 10},
+		{given: "200mb", wants: 200 << 20},
+		{given: "1.5G", wants: 3 << 29},
+		{given: "lots", wantsError: true},
+		{given: "-1MB", wantsError: true},
+	}
+	for _, test := range tests {
+		t.Run(test.given, func(t *testing.T) {
+			v, err := ParseSize(test.given)
+			if test.wantsError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, test.wants, v)
+			}
+		})
+	}
+}
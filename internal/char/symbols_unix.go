@@ -3,6 +3,10 @@
 package char
 
 const (
-	SymSearch = "🔎"
-	SymKey    = "🔑"
+	SymSearch   = "🔎"
+	SymKey      = "🔑"
+	SymNewLine  = "⏎"
+	SymDivider  = "┄"
+	SymSortAsc  = "▲"
+	SymSortDesc = "▼"
 )
@@ -3,6 +3,10 @@
 package char
 
 const (
-	SymSearch = "ƒ"
-	SymKey    = "≡"
+	SymSearch   = "ƒ"
+	SymKey      = "≡"
+	SymNewLine  = "¶"
+	SymDivider  = "-"
+	SymSortAsc  = "^"
+	SymSortDesc = "v"
 )
@@ -47,6 +47,9 @@ func MakeConfigFromSample(sample []map[string]any, mergeWith ...Key) (*Config, m
 			if timestamp.Contains(k) {
 				keyMap[k] = timestamp.keyConfig(k)
 				continue
+			} else if source.Contains(k) {
+				keyMap[k] = source.keyConfig(k)
+				continue
 			} else if logType.Contains(k) {
 				keyMap[k] = logType.keyConfig(k)
 				continue
@@ -80,6 +83,7 @@ func MakeConfigFromSample(sample []map[string]any, mergeWith ...Key) (*Config, m
 	}
 	var orderedKeys []string
 	orderedKeys = append(orderedKeys, timestamp.Keys()...)
+	orderedKeys = append(orderedKeys, source.Keys()...)
 	orderedKeys = append(orderedKeys, logType.Keys()...)
 	orderedKeys = append(orderedKeys, traceId.Keys()...)
 	orderedKeys = append(orderedKeys, message.Keys()...)
@@ -92,7 +96,7 @@ func MakeConfigFromSample(sample []map[string]any, mergeWith ...Key) (*Config, m
 
 	var sk []string
 	for k := range keyMap {
-		if !timestamp.Contains(k) && !message.Contains(k) && !traceId.Contains(k) && !logType.Contains(k) && !errorKey.Contains(k) {
+		if !timestamp.Contains(k) && !source.Contains(k) && !message.Contains(k) && !traceId.Contains(k) && !logType.Contains(k) && !errorKey.Contains(k) {
 			sk = append(sk, k)
 		}
 	}
@@ -160,6 +164,20 @@ var (
 			}
 		},
 	}
+	source = preBakedRule{
+		keyMatchesAny: map[string]bool{Source: true},
+		keyConfig: func(keyName string) *Key {
+			return &Key{
+				Name:     keyName,
+				Type:     TypeString,
+				MaxWidth: 20,
+				Color: Color{
+					Foreground: "teal",
+					Background: "black",
+				},
+			}
+		},
+	}
 	traceId = preBakedRule{
 		keyMatchesAny: map[string]bool{"traceId": true},
 		keyConfig: func(keyName string) *Key {
@@ -220,6 +238,7 @@ var (
 	message = preBakedRule{
 		keyMatchesAny: map[string]bool{
 			"message":             true,
+			"msg":                 true,
 			"jsonPayload/message": true,
 			"http_request":        true,
 		},
@@ -0,0 +1,67 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package config
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+//go:embed templates/*.yaml
+var builtinTemplates embed.FS
+
+// TemplatesDir is where built-in templates are installed, ~/.loggo/templates.
+func TemplatesDir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".loggo", "templates"), nil
+}
+
+// InstallTemplate writes the built-in template of the given name (e.g.
+// "journal") to dir and returns its path. A file already there is left as
+// is, so the template can be customised and saved from the template view.
+func InstallTemplate(dir, name string) (string, error) {
+	b, err := builtinTemplates.ReadFile("templates/" + name + ".yaml")
+	if err != nil {
+		return "", fmt.Errorf("no built-in template %s", name)
+	}
+	fileName := filepath.Join(dir, name+".yaml")
+	if _, err := os.Stat(fileName); err == nil {
+		return fileName, nil
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return "", err
+	}
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return "", err
+	}
+	if err := os.WriteFile(fileName, b, 0644); err != nil {
+		return "", err
+	}
+	return fileName, nil
+}
@@ -0,0 +1,85 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestInstallTemplate(t *testing.T) {
+	tests := []struct {
+		name          string
+		givenTemplate string
+		givenExisting string
+		wantKeys      []string
+		wantError     bool
+	}{
+		{
+			name:          "Installs the journal template",
+			givenTemplate: "journal",
+			wantKeys:      []string{"timestamp", "host", "unit", "severity", "message"},
+		},
+		{
+			name:          "Installs the syslog template",
+			givenTemplate: "syslog",
+			wantKeys:      []string{"timestamp", "host", "app", "facility", "severity", "message"},
+		},
+		{
+			name:          "Keeps a customised template",
+			givenTemplate: "journal",
+			givenExisting: "keys:\n  - name: message\n    type: string\n",
+			wantKeys:      []string{"message"},
+		},
+		{
+			name:          "Unknown template",
+			givenTemplate: "foo",
+			wantError:     true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dir := filepath.Join(t.TempDir(), "templates")
+			if len(test.givenExisting) > 0 {
+				assert.NoError(t, os.MkdirAll(dir, os.ModePerm))
+				assert.NoError(t, os.WriteFile(filepath.Join(dir, test.givenTemplate+".yaml"), []byte(test.givenExisting), 0644))
+			}
+			fileName, err := InstallTemplate(dir, test.givenTemplate)
+			if test.wantError {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			c, err := MakeConfig(fileName)
+			assert.NoError(t, err)
+			var keys []string
+			for _, k := range c.Keys {
+				keys = append(keys, k.Name)
+			}
+			assert.Equal(t, test.wantKeys, keys)
+		})
+	}
+}
@@ -27,6 +27,7 @@ import (
 	"fmt"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/gdamore/tcell/v2"
 
@@ -36,13 +37,30 @@ import (
 const (
 	ParseErr    = "$_parseErr"
 	TextPayload = "message"
+	Source      = "$_source"
+
+	DefaultHighlightBackground = "#303060"
 )
 
 type Config struct {
-	Keys          []Key  `json:"keys" yaml:"keys"`
+	Keys    []Key    `json:"keys" yaml:"keys"`
+	Parsers []Parser `json:"parsers,omitempty" yaml:"parsers,omitempty"`
+	// Highlight is the background of the rows matching the local filter in
+	// highlight mode.
+	Highlight     Color  `json:"highlight,omitempty" yaml:"highlight,omitempty"`
 	LastSavedName string `json:"-" yaml:"-"`
 }
 
+// Parser turns plain-text lines (i.e. non json) into structured entries using a
+// regular expression. Each named capture, e.g. (?P<status>\d{3}), becomes a key.
+// Grok-like references such as %{COMBINEDAPACHELOG} or %{NUMBER:bytes:int} are
+// expanded from a built-in pattern library. Parsers are tried in the declared
+// order and the first that matches wins.
+type Parser struct {
+	Name    string `json:"name" yaml:"name"`
+	Pattern string `json:"pattern" yaml:"pattern"`
+}
+
 func (c *Config) Save(fileName string) error {
 	b, err := yaml.Marshal(c)
 	if err != nil {
@@ -59,6 +77,15 @@ func (c *Config) Save(fileName string) error {
 	return nil
 }
 
+// HighlightBackground is the background of the rows matching the local filter
+// in highlight mode.
+func (c *Config) HighlightBackground() tcell.Color {
+	if len(c.Highlight.Background) > 0 {
+		return c.Highlight.GetBackgroundColor()
+	}
+	return tcell.GetColor(DefaultHighlightBackground)
+}
+
 func (c *Config) KeyMap() map[string]*Key {
 	nk := make(map[string]*Key)
 	for _, k := range c.Keys {
@@ -145,11 +172,30 @@ func (k *Key) ExtractValue(m map[string]any) string {
 			}
 			return fmt.Sprintf("%+v", lv)
 		}
-		level = lv.(map[string]any)
+		m, ok := lv.(map[string]any)
+		if !ok {
+			return val
+		}
+		level = m
 	}
 	return val
 }
 
+// ExtractTime parses the key's value in the entry with the key's layout
+// (RFC3339 if none). Layouts without a time zone are read as local time.
+func (k *Key) ExtractTime(m map[string]any) (time.Time, bool) {
+	v := k.ExtractValue(m)
+	if len(v) == 0 {
+		return time.Time{}, false
+	}
+	layout := k.Layout
+	if len(layout) == 0 {
+		layout = time.RFC3339Nano
+	}
+	t, err := time.ParseInLocation(layout, v, time.Local)
+	return t, err == nil
+}
+
 func MakeConfig(file string) (*Config, error) {
 	var yamlBytes []byte
 	config := Config{}
@@ -25,7 +25,9 @@ package config
 import (
 	"encoding/json"
 	"testing"
+	"time"
 
+	"github.com/gdamore/tcell/v2"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -104,6 +106,14 @@ func TestKey_ExtractValue(t *testing.T) {
 			givenJson: []byte(`{"a":{"b":{"value": 1}}}`),
 			wantValue: "1",
 		},
+		{
+			name: "Multi level key through a plain value",
+			givenKey: &Key{
+				Name: "a/b/value",
+			},
+			givenJson: []byte(`{"a":"b"}`),
+			wantValue: "",
+		},
 	}
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
@@ -116,6 +126,55 @@ func TestKey_ExtractValue(t *testing.T) {
 	}
 }
 
+func TestKey_ExtractTime(t *testing.T) {
+	tests := []struct {
+		name     string
+		givenKey *Key
+		givenRow map[string]any
+		wantTime time.Time
+		wantOk   bool
+	}{
+		{
+			name:     "Default RFC3339 layout",
+			givenKey: &Key{Name: "ts"},
+			givenRow: map[string]any{"ts": "2023-01-02T10:00:00Z"},
+			wantTime: time.Date(2023, 1, 2, 10, 0, 0, 0, time.UTC),
+			wantOk:   true,
+		},
+		{
+			name:     "Layout without a zone is local time",
+			givenKey: &Key{Name: "a/ts", Layout: "2006-01-02 15:04"},
+			givenRow: map[string]any{"a": map[string]any{"ts": "2023-01-02 10:00"}},
+			wantTime: time.Date(2023, 1, 2, 10, 0, 0, 0, time.Local),
+			wantOk:   true,
+		},
+		{
+			name:     "Missing value",
+			givenKey: &Key{Name: "ts"},
+			givenRow: map[string]any{},
+		},
+		{
+			name:     "Value not in the layout",
+			givenKey: &Key{Name: "ts"},
+			givenRow: map[string]any{"ts": "yesterday"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, ok := test.givenKey.ExtractTime(test.givenRow)
+			assert.Equal(t, test.wantOk, ok)
+			assert.True(t, test.wantTime.Equal(got))
+		})
+	}
+}
+
+func TestConfig_HighlightBackground(t *testing.T) {
+	c := Config{}
+	assert.Equal(t, tcell.GetColor(DefaultHighlightBackground), c.HighlightBackground())
+	c.Highlight.Background = "DarkRed"
+	assert.Equal(t, tcell.ColorDarkRed, c.HighlightBackground())
+}
+
 var defConfig = Config{
 	Keys: []Key{
 		{
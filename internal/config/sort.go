@@ -0,0 +1,84 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package config
+
+import (
+	"cmp"
+	"strconv"
+	"strings"
+)
+
+// SortValue is the value of a key in an entry, prepared for ordering entries
+// by that key.
+type SortValue struct {
+	present bool
+	ordinal bool
+	number  float64
+	text    string
+}
+
+// SortValue extracts the key's value from the entry so entries can be sorted
+// by it: numbers numerically, datetimes chronologically (parsed with the key's
+// layout) and everything else as case-insensitive text. Values that can't be
+// parsed as the key's type fall back to text.
+func (k *Key) SortValue(m map[string]any) SortValue {
+	v := k.ExtractValue(m)
+	if len(v) == 0 {
+		return SortValue{}
+	}
+	sv := SortValue{present: true, text: strings.ToLower(v)}
+	switch k.Type {
+	case TypeNumber:
+		if n, err := strconv.ParseFloat(v, 64); err == nil {
+			sv.ordinal, sv.number = true, n
+		}
+	case TypeDateTime:
+		if t, ok := k.ExtractTime(m); ok {
+			sv.ordinal, sv.number = true, float64(t.UnixNano())
+		}
+	}
+	return sv
+}
+
+// Compare returns -1, 0 or +1 depending on whether v sorts before, together
+// with or after o. Missing values sort first, then parsed values, then
+// values that couldn't be parsed as the key's type.
+func (v SortValue) Compare(o SortValue) int {
+	if c := cmp.Compare(v.rank(), o.rank()); c != 0 {
+		return c
+	}
+	if v.ordinal {
+		return cmp.Compare(v.number, o.number)
+	}
+	return strings.Compare(v.text, o.text)
+}
+
+func (v SortValue) rank() int {
+	switch {
+	case !v.present:
+		return 0
+	case v.ordinal:
+		return 1
+	}
+	return 2
+}
@@ -0,0 +1,95 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKey_SortValue(t *testing.T) {
+	tests := []struct {
+		name  string
+		key   Key
+		a     map[string]any
+		b     map[string]any
+		wants int
+	}{
+		{
+			name:  "Numbers compare numerically",
+			key:   Key{Name: "latency", Type: TypeNumber},
+			a:     map[string]any{"latency": 9},
+			b:     map[string]any{"latency": 120.5},
+			wants: -1,
+		},
+		{
+			name:  "Strings compare as text ignoring case",
+			key:   Key{Name: "msg", Type: TypeString},
+			a:     map[string]any{"msg": "beta"},
+			b:     map[string]any{"msg": "Alpha"},
+			wants: 1,
+		},
+		{
+			name:  "Datetimes compare chronologically with the key layout",
+			key:   Key{Name: "ts", Type: TypeDateTime, Layout: "02/01/2006 15:04"},
+			a:     map[string]any{"ts": "02/01/2023 10:00"},
+			b:     map[string]any{"ts": "01/02/2023 09:00"},
+			wants: -1,
+		},
+		{
+			name:  "Datetimes default to RFC3339 and honour the offset",
+			key:   Key{Name: "ts", Type: TypeDateTime},
+			a:     map[string]any{"ts": "2023-01-02T10:00:00+10:00"},
+			b:     map[string]any{"ts": "2023-01-02T01:00:00Z"},
+			wants: -1,
+		},
+		{
+			name:  "Nested keys",
+			key:   Key{Name: "http/status", Type: TypeNumber},
+			a:     map[string]any{"http": map[string]any{"status": 500}},
+			b:     map[string]any{"http": map[string]any{"status": 500}},
+			wants: 0,
+		},
+		{
+			name:  "Missing values sort first",
+			key:   Key{Name: "latency", Type: TypeNumber},
+			a:     map[string]any{"latency": -3},
+			b:     map[string]any{},
+			wants: 1,
+		},
+		{
+			name:  "Unparseable values sort after parsed ones",
+			key:   Key{Name: "latency", Type: TypeNumber},
+			a:     map[string]any{"latency": "n/a"},
+			b:     map[string]any{"latency": 1000},
+			wants: 1,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := test.key.SortValue(test.a).Compare(test.key.SortValue(test.b))
+			assert.Equal(t, test.wants, got)
+		})
+	}
+}
@@ -0,0 +1,106 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package decoder
+
+import (
+	"encoding/json"
+
+	"github.com/aurc/loggo/internal/config"
+)
+
+// Decoder turns a single raw log line into a structured entry.
+type Decoder interface {
+	// Decode returns the entry and true if the line is in a format this
+	// decoder understands, otherwise it returns false.
+	Decode(line string) (map[string]any, bool)
+	// Name identifies the decoder.
+	Name() string
+}
+
+// Chain tries each decoder in order and falls back to an unparsed entry.
+type Chain []Decoder
+
+// Default returns the built-in chain: json followed by logfmt.
+func Default() Chain {
+	return Chain{JSON(), Logfmt()}
+}
+
+// Decode decodes line with the first decoder that understands it. Entries that
+// arrive already wrapped as unparsed (e.g. tagged by a reader) are decoded
+// again from their text payload, keeping any other keys the wrapper carried.
+func (c Chain) Decode(line string) map[string]any {
+	for _, d := range c {
+		m, ok := d.Decode(line)
+		if !ok {
+			continue
+		}
+		if _, unparsed := m[config.ParseErr]; unparsed {
+			if text, ok := m[config.TextPayload].(string); ok && text != line {
+				return c.redecode(text, m)
+			}
+		}
+		return m
+	}
+	return Unparsed(line, "unrecognised log format")
+}
+
+func (c Chain) redecode(text string, wrapper map[string]any) map[string]any {
+	m := c.Decode(text)
+	for k, v := range wrapper {
+		if k == config.ParseErr || k == config.TextPayload {
+			continue
+		}
+		if _, ok := m[k]; !ok {
+			m[k] = v
+		}
+	}
+	return m
+}
+
+// Unparsed wraps a line that couldn't be decoded in the shape the log view
+// renders as a parse error row.
+func Unparsed(line, reason string) map[string]any {
+	return map[string]any{
+		config.ParseErr:    reason,
+		config.TextPayload: line,
+	}
+}
+
+type jsonDecoder struct{}
+
+// JSON decodes lines holding a json object.
+func JSON() Decoder {
+	return jsonDecoder{}
+}
+
+func (jsonDecoder) Decode(line string) (map[string]any, bool) {
+	m := make(map[string]any)
+	if err := json.Unmarshal([]byte(line), &m); err != nil || m == nil {
+		return nil, false
+	}
+	return m, true
+}
+
+func (jsonDecoder) Name() string {
+	return "json"
+}
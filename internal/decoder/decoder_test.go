@@ -0,0 +1,88 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package decoder
+
+import (
+	"testing"
+
+	"github.com/aurc/loggo/internal/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestChain_Decode(t *testing.T) {
+	tests := []struct {
+		name      string
+		givenLine string
+		wantsMap  map[string]any
+	}{
+		{
+			name:      "json",
+			givenLine: `{"level":"info","n":1}`,
+			wantsMap:  map[string]any{"level": "info", "n": float64(1)},
+		},
+		{
+			name:      "logfmt",
+			givenLine: `level=info msg="hello world"`,
+			wantsMap:  map[string]any{"level": "info", "msg": "hello world"},
+		},
+		{
+			name:      "plain text",
+			givenLine: `hello world`,
+			wantsMap: map[string]any{
+				config.ParseErr:    "unrecognised log format",
+				config.TextPayload: "hello world",
+			},
+		},
+		{
+			name:      "json null is not an entry",
+			givenLine: `null`,
+			wantsMap: map[string]any{
+				config.ParseErr:    "unrecognised log format",
+				config.TextPayload: "null",
+			},
+		},
+		{
+			name:      "unparsed wrapper from a tagging reader",
+			givenLine: `{"$_parseErr":"x","message":"level=warn msg=slow","$_source":"a.log"}`,
+			wantsMap: map[string]any{
+				"level":       "warn",
+				"msg":         "slow",
+				config.Source: "a.log",
+			},
+		},
+		{
+			name:      "unparsed wrapper with plain text",
+			givenLine: `{"$_parseErr":"x","message":"oops","$_source":"a.log"}`,
+			wantsMap: map[string]any{
+				config.ParseErr:    "unrecognised log format",
+				config.TextPayload: "oops",
+				config.Source:      "a.log",
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.wantsMap, Default().Decode(test.givenLine))
+		})
+	}
+}
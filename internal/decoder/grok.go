@@ -0,0 +1,101 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package decoder
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// grokPatterns is a trimmed down version of the logstash grok library,
+// covering the formats most often found alongside json logs.
+var grokPatterns = map[string]string{
+	"USERNAME":          `[a-zA-Z0-9._-]+`,
+	"USER":              `%{USERNAME}`,
+	"INT":               `[+-]?[0-9]+`,
+	"NUMBER":            `[+-]?(?:[0-9]+(?:\.[0-9]+)?|\.[0-9]+)`,
+	"WORD":              `\b\w+\b`,
+	"NOTSPACE":          `\S+`,
+	"SPACE":             `\s*`,
+	"DATA":              `.*?`,
+	"GREEDYDATA":        `.*`,
+	"QUOTEDSTRING":      `"(?:[^"\\]|\\.)*"`,
+	"QS":                `%{QUOTEDSTRING}`,
+	"IPV4":              `(?:(?:25[0-5]|2[0-4][0-9]|[01]?[0-9][0-9]?)\.){3}(?:25[0-5]|2[0-4][0-9]|[01]?[0-9][0-9]?)`,
+	"IPV6":              `(?:[0-9A-Fa-f]{0,4}:){2,7}[0-9A-Fa-f]{0,4}`,
+	"IP":                `(?:%{IPV6}|%{IPV4})`,
+	"HOSTNAME":          `\b[0-9A-Za-z][0-9A-Za-z-]{0,62}(?:\.[0-9A-Za-z][0-9A-Za-z-]{0,62})*\.?`,
+	"IPORHOST":          `(?:%{IP}|%{HOSTNAME})`,
+	"MONTH":             `\b(?:Jan|Feb|Mar|Apr|May|Jun|Jul|Aug|Sep|Oct|Nov|Dec)[a-z]*\b`,
+	"MONTHDAY":          `(?:0[1-9]|[12][0-9]|3[01]|[1-9])`,
+	"YEAR":              `[0-9]{2,4}`,
+	"HOUR":              `(?:2[0123]|[01]?[0-9])`,
+	"MINUTE":            `[0-5][0-9]`,
+	"SECOND":            `(?:[0-5]?[0-9]|60)(?:[:.,][0-9]+)?`,
+	"TIME":              `%{HOUR}:%{MINUTE}(?::%{SECOND})?`,
+	"HTTPDATE":          `%{MONTHDAY}/%{MONTH}/%{YEAR}:%{TIME} %{INT}`,
+	"TIMESTAMP_ISO8601": `[0-9]{4}-[0-9]{2}-[0-9]{2}[T ]%{HOUR}:?%{MINUTE}(?::?%{SECOND})?(?:Z|[+-]%{HOUR}(?::?%{MINUTE})?)?`,
+	"LOGLEVEL":          `(?i:alert|trace|debug|notice|info|warn(?:ing)?|err(?:or)?|crit(?:ical)?|fatal|severe|emerg(?:ency)?)`,
+	"JAVACLASS":         `(?:[a-zA-Z$_][a-zA-Z$_0-9]*\.)*[a-zA-Z$_][a-zA-Z$_0-9]*`,
+	"URIPATHPARAM":      `\S+`,
+	"COMMONAPACHELOG": `%{IPORHOST:clientip} %{USER:ident} %{USER:auth} \[%{HTTPDATE:timestamp}\] ` +
+		`"(?:%{WORD:verb} %{NOTSPACE:request}(?: HTTP/%{NUMBER:httpversion})?|%{DATA:rawrequest})" ` +
+		`%{NUMBER:response:int} (?:%{NUMBER:bytes:int}|-)`,
+	"COMBINEDAPACHELOG": `%{COMMONAPACHELOG} %{QS:referrer} %{QS:agent}`,
+}
+
+var grokRef = regexp.MustCompile(`%\{(\w+)(?::(\w+))?(?::(int|float))?\}`)
+
+const maxGrokDepth = 20
+
+// expandGrok replaces %{PATTERN}, %{PATTERN:name} and %{PATTERN:name:type}
+// references with their regular expressions. Named references become named
+// captures and the optional type is returned per capture name.
+func expandGrok(pattern string) (string, map[string]string, error) {
+	types := make(map[string]string)
+	var err error
+	for depth := 0; grokRef.MatchString(pattern); depth++ {
+		if depth == maxGrokDepth {
+			return "", nil, fmt.Errorf("grok patterns nested too deep")
+		}
+		pattern = grokRef.ReplaceAllStringFunc(pattern, func(ref string) string {
+			sub := grokRef.FindStringSubmatch(ref)
+			def, ok := grokPatterns[sub[1]]
+			if !ok {
+				err = fmt.Errorf("unknown grok pattern %s", sub[1])
+				return ""
+			}
+			if len(sub[2]) == 0 {
+				return `(?:` + def + `)`
+			}
+			if len(sub[3]) > 0 {
+				types[sub[2]] = sub[3]
+			}
+			return fmt.Sprintf(`(?P<%s>%s)`, sub[2], def)
+		})
+		if err != nil {
+			return "", nil, err
+		}
+	}
+	return pattern, types, nil
+}
@@ -0,0 +1,120 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package decoder
+
+import (
+	"strings"
+)
+
+type logfmtDecoder struct{}
+
+// Logfmt decodes key=value lines such as the ones produced by logrus' text
+// formatter or Heroku's router, e.g.:
+//
+//	level=info msg="request served" path=/health dur=12ms
+//
+// A line is only accepted if every token is a key=value pair, so free text
+// that happens to contain an '=' isn't mistaken for logfmt.
+func Logfmt() Decoder {
+	return logfmtDecoder{}
+}
+
+func (logfmtDecoder) Decode(line string) (map[string]any, bool) {
+	line = strings.TrimSpace(line)
+	if len(line) == 0 {
+		return nil, false
+	}
+	m := make(map[string]any)
+	i := 0
+	for i < len(line) {
+		for i < len(line) && line[i] == ' ' {
+			i++
+		}
+		if i == len(line) {
+			break
+		}
+		start := i
+		for i < len(line) && line[i] != '=' && line[i] != ' ' && line[i] != '"' {
+			i++
+		}
+		if i == start || i == len(line) || line[i] != '=' {
+			return nil, false
+		}
+		key := line[start:i]
+		i++
+		var val string
+		if i < len(line) && line[i] == '"' {
+			var ok bool
+			val, i, ok = unquote(line, i)
+			if !ok {
+				return nil, false
+			}
+			if i < len(line) && line[i] != ' ' {
+				return nil, false
+			}
+		} else {
+			vs := i
+			for i < len(line) && line[i] != ' ' {
+				if line[i] == '"' {
+					return nil, false
+				}
+				i++
+			}
+			val = line[vs:i]
+		}
+		m[key] = val
+	}
+	return m, len(m) > 0
+}
+
+func (logfmtDecoder) Name() string {
+	return "logfmt"
+}
+
+// unquote reads a double-quoted value starting at line[i] and returns it
+// together with the index right after the closing quote.
+func unquote(line string, i int) (string, int, bool) {
+	sb := strings.Builder{}
+	for i++; i < len(line); i++ {
+		switch line[i] {
+		case '\\':
+			if i+1 == len(line) {
+				return "", i, false
+			}
+			i++
+			switch line[i] {
+			case 'n':
+				sb.WriteByte('\n')
+			case 't':
+				sb.WriteByte('\t')
+			default:
+				sb.WriteByte(line[i])
+			}
+		case '"':
+			return sb.String(), i + 1, true
+		default:
+			sb.WriteByte(line[i])
+		}
+	}
+	return "", i, false
+}
@@ -0,0 +1,94 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package decoder
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogfmt_Decode(t *testing.T) {
+	tests := []struct {
+		name      string
+		givenLine string
+		wantsMap  map[string]any
+		wantsOk   bool
+	}{
+		{
+			name:      "logrus text formatter",
+			givenLine: `time="2022-07-30T15:00:00Z" level=info msg="request served" dur=12ms`,
+			wantsMap: map[string]any{
+				"time":  "2022-07-30T15:00:00Z",
+				"level": "info",
+				"msg":   "request served",
+				"dur":   "12ms",
+			},
+			wantsOk: true,
+		},
+		{
+			name:      "heroku router",
+			givenLine: `at=info method=GET path="/" fwd="1.2.3.4" dyno=web.1 status=200 bytes=1234`,
+			wantsMap: map[string]any{
+				"at":     "info",
+				"method": "GET",
+				"path":   "/",
+				"fwd":    "1.2.3.4",
+				"dyno":   "web.1",
+				"status": "200",
+				"bytes":  "1234",
+			},
+			wantsOk: true,
+		},
+		{
+			name:      "escaped quotes and empty value",
+			givenLine: `msg="said \"hi\"" err=`,
+			wantsMap: map[string]any{
+				"msg": `said "hi"`,
+				"err": "",
+			},
+			wantsOk: true,
+		},
+		{
+			name:      "free text with an equals sign",
+			givenLine: `user logged in with id=5`,
+		},
+		{
+			name:      "unterminated quote",
+			givenLine: `msg="broken level=info`,
+		},
+		{
+			name:      "blank line",
+			givenLine: `   `,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			m, ok := Logfmt().Decode(test.givenLine)
+			assert.Equal(t, test.wantsOk, ok)
+			if test.wantsOk {
+				assert.Equal(t, test.wantsMap, m)
+			}
+		})
+	}
+}
@@ -0,0 +1,103 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package decoder
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+
+	"github.com/aurc/loggo/internal/config"
+)
+
+type regexDecoder struct {
+	name  string
+	reg   *regexp.Regexp
+	types map[string]string
+}
+
+// Regex builds a decoder from a template parser definition. Grok references
+// are expanded before the pattern is compiled.
+func Regex(p config.Parser) (Decoder, error) {
+	expanded, types, err := expandGrok(p.Pattern)
+	if err != nil {
+		return nil, fmt.Errorf("parser %s: %w", p.Name, err)
+	}
+	reg, err := regexp.Compile(expanded)
+	if err != nil {
+		return nil, fmt.Errorf("parser %s: %w", p.Name, err)
+	}
+	if len(reg.SubexpNames()) < 2 {
+		return nil, fmt.Errorf("parser %s: pattern has no named captures", p.Name)
+	}
+	return &regexDecoder{
+		name:  p.Name,
+		reg:   reg,
+		types: types,
+	}, nil
+}
+
+func (d *regexDecoder) Decode(line string) (map[string]any, bool) {
+	match := d.reg.FindStringSubmatch(line)
+	if match == nil {
+		return nil, false
+	}
+	m := make(map[string]any)
+	for i, name := range d.reg.SubexpNames() {
+		if i == 0 || len(name) == 0 || len(match[i]) == 0 {
+			continue
+		}
+		m[name] = d.convert(name, match[i])
+	}
+	return m, len(m) > 0
+}
+
+func (d *regexDecoder) convert(name, value string) any {
+	switch d.types[name] {
+	case "int", "float":
+		if n, err := strconv.ParseFloat(value, 64); err == nil {
+			return n
+		}
+	}
+	return value
+}
+
+func (d *regexDecoder) Name() string {
+	return d.name
+}
+
+// ForConfig builds the decoder chain for a template: json first, then the
+// template's parsers in the declared order and logfmt last.
+func ForConfig(c *config.Config) (Chain, error) {
+	chain := Chain{JSON()}
+	if c != nil {
+		for _, p := range c.Parsers {
+			d, err := Regex(p)
+			if err != nil {
+				return Default(), err
+			}
+			chain = append(chain, d)
+		}
+	}
+	return append(chain, Logfmt()), nil
+}
@@ -0,0 +1,141 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package decoder
+
+import (
+	"testing"
+
+	"github.com/aurc/loggo/internal/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRegex_Decode(t *testing.T) {
+	tests := []struct {
+		name        string
+		givenParser config.Parser
+		givenLine   string
+		wantsMap    map[string]any
+		wantsOk     bool
+		wantsError  bool
+	}{
+		{
+			name: "named captures",
+			givenParser: config.Parser{
+				Name:    "simple",
+				Pattern: `^(?P<level>[A-Z]+) (?P<msg>.*)$`,
+			},
+			givenLine: `WARN disk almost full`,
+			wantsMap:  map[string]any{"level": "WARN", "msg": "disk almost full"},
+			wantsOk:   true,
+		},
+		{
+			name: "combined apache log",
+			givenParser: config.Parser{
+				Name:    "nginx",
+				Pattern: `^%{COMBINEDAPACHELOG}`,
+			},
+			givenLine: `127.0.0.1 - frank [10/Oct/2000:13:55:36 -0700] "GET /apache_pb.gif HTTP/1.0" 200 2326 "http://www.example.com/start.html" "Mozilla/4.08"`,
+			wantsMap: map[string]any{
+				"clientip":    "127.0.0.1",
+				"ident":       "-",
+				"auth":        "frank",
+				"timestamp":   "10/Oct/2000:13:55:36 -0700",
+				"verb":        "GET",
+				"request":     "/apache_pb.gif",
+				"httpversion": "1.0",
+				"response":    float64(200),
+				"bytes":       float64(2326),
+				"referrer":    `"http://www.example.com/start.html"`,
+				"agent":       `"Mozilla/4.08"`,
+			},
+			wantsOk: true,
+		},
+		{
+			name: "typed grok capture",
+			givenParser: config.Parser{
+				Name:    "latency",
+				Pattern: `took %{NUMBER:latency:float}ms`,
+			},
+			givenLine: `request took 12.5ms`,
+			wantsMap:  map[string]any{"latency": 12.5},
+			wantsOk:   true,
+		},
+		{
+			name: "no match",
+			givenParser: config.Parser{
+				Name:    "simple",
+				Pattern: `^(?P<level>[A-Z]+) `,
+			},
+			givenLine: `lowercase line`,
+		},
+		{
+			name: "unknown grok pattern",
+			givenParser: config.Parser{
+				Name:    "bad",
+				Pattern: `%{NOPE:x}`,
+			},
+			wantsError: true,
+		},
+		{
+			name: "no named captures",
+			givenParser: config.Parser{
+				Name:    "bad",
+				Pattern: `^[A-Z]+`,
+			},
+			wantsError: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			d, err := Regex(test.givenParser)
+			if test.wantsError {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			m, ok := d.Decode(test.givenLine)
+			assert.Equal(t, test.wantsOk, ok)
+			if test.wantsOk {
+				assert.Equal(t, test.wantsMap, m)
+			}
+		})
+	}
+}
+
+func TestForConfig(t *testing.T) {
+	c, err := config.MakeConfig("../config-sample/nginx.yaml")
+	assert.NoError(t, err)
+	chain, err := ForConfig(c)
+	assert.NoError(t, err)
+	assert.Len(t, chain, 4)
+
+	m := chain.Decode(`java.lang.IllegalStateException: boom`)
+	assert.Equal(t, "java.lang.IllegalStateException", m["exception"])
+	assert.Equal(t, "boom", m["message"])
+
+	m = chain.Decode(`level=info msg=hi`)
+	assert.Equal(t, "hi", m["msg"])
+
+	_, err = ForConfig(&config.Config{Parsers: []config.Parser{{Name: "bad", Pattern: `(`}}})
+	assert.Error(t, err)
+}
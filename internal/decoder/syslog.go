@@ -0,0 +1,228 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package decoder
+
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Keys of the entries decoded from syslog messages.
+const (
+	SyslogTimestamp = "timestamp"
+	SyslogHost      = "host"
+	SyslogApp       = "app"
+	SyslogProcID    = "procid"
+	SyslogMsgID     = "msgid"
+	SyslogFacility  = "facility"
+	SyslogSeverity  = "severity"
+	SyslogData      = "sd"
+	SyslogMessage   = "message"
+)
+
+var severities = []string{"EMERG", "ALERT", "CRIT", "ERROR", "WARN", "NOTICE", "INFO", "DEBUG"}
+
+var facilities = []string{"kern", "user", "mail", "daemon", "auth", "syslog", "lpr", "news",
+	"uucp", "cron", "authpriv", "ftp", "ntp", "security", "console", "solaris-cron",
+	"local0", "local1", "local2", "local3", "local4", "local5", "local6", "local7"}
+
+// SeverityName names a syslog severity, 0 (EMERG) to 7 (DEBUG).
+func SeverityName(severity int) (string, bool) {
+	if severity < 0 || severity >= len(severities) {
+		return "", false
+	}
+	return severities[severity], true
+}
+
+type syslogDecoder struct {
+	now func() time.Time
+}
+
+// Syslog decodes RFC 5424 and RFC 3164 (BSD) syslog messages, e.g.:
+//
+//	<165>1 2003-10-11T22:14:15.003Z mymachine.example.com evntslog - ID47 [exampleSDID@32473 iut="3"] An application event
+//	<34>Oct 11 22:14:15 mymachine su[230]: 'su root' failed for lonvick on /dev/pts/8
+//
+// The priority is split into facility and severity names, and the structured
+// data elements of RFC 5424 become nested under the sd key, e.g.
+// sd/exampleSDID@32473/iut. RFC 3164 timestamps have no year, the current one
+// is assumed.
+func Syslog() Decoder {
+	return syslogDecoder{now: time.Now}
+}
+
+func (syslogDecoder) Name() string {
+	return "syslog"
+}
+
+func (d syslogDecoder) Decode(line string) (map[string]any, bool) {
+	line = strings.TrimRight(line, "\r\n\x00")
+	if len(line) < 3 || line[0] != '<' {
+		return nil, false
+	}
+	end := strings.IndexByte(line, '>')
+	if end < 2 || end > 4 {
+		return nil, false
+	}
+	pri, err := strconv.Atoi(line[1:end])
+	if err != nil || pri < 0 || pri > 191 {
+		return nil, false
+	}
+	m := map[string]any{
+		SyslogFacility: facilities[pri/8],
+		SyslogSeverity: severities[pri%8],
+	}
+	rest := line[end+1:]
+	if len(rest) > 2 && rest[0] >= '1' && rest[0] <= '9' && rest[1] == ' ' {
+		if d.decode5424(rest[2:], m) {
+			return m, true
+		}
+	}
+	d.decode3164(rest, m)
+	return m, true
+}
+
+// decode5424 reads TIMESTAMP HOSTNAME APP-NAME PROCID MSGID STRUCTURED-DATA
+// [MSG], where "-" stands for a missing value.
+func (d syslogDecoder) decode5424(rest string, m map[string]any) bool {
+	fields := make([]string, 0, 5)
+	for range 5 {
+		i := strings.IndexByte(rest, ' ')
+		if i < 0 {
+			return false
+		}
+		fields = append(fields, rest[:i])
+		rest = rest[i+1:]
+	}
+	if fields[0] != "-" {
+		if _, err := time.Parse(time.RFC3339Nano, fields[0]); err != nil {
+			return false
+		}
+	}
+	for i, k := range []string{SyslogTimestamp, SyslogHost, SyslogApp, SyslogProcID, SyslogMsgID} {
+		if fields[i] != "-" {
+			m[k] = fields[i]
+		}
+	}
+	if strings.HasPrefix(rest, "-") {
+		rest = rest[1:]
+	} else {
+		sd, n, ok := structuredData(rest)
+		if !ok {
+			return false
+		}
+		m[SyslogData] = sd
+		rest = rest[n:]
+	}
+	rest = strings.TrimPrefix(rest, " ")
+	m[SyslogMessage] = strings.TrimPrefix(rest, "\ufeff")
+	return true
+}
+
+// structuredData reads [id param="value" ...] elements, returning them by id
+// along with how much of s they took.
+func structuredData(s string) (map[string]any, int, bool) {
+	sd := make(map[string]any)
+	i := 0
+	for i < len(s) && s[i] == '[' {
+		i++
+		start := i
+		for i < len(s) && s[i] != ' ' && s[i] != ']' {
+			i++
+		}
+		if i == len(s) || i == start {
+			return nil, 0, false
+		}
+		params := make(map[string]any)
+		sd[s[start:i]] = params
+		for i < len(s) && s[i] == ' ' {
+			i++
+			start = i
+			for i < len(s) && s[i] != '=' {
+				i++
+			}
+			if i+1 >= len(s) || s[i+1] != '"' {
+				return nil, 0, false
+			}
+			name := s[start:i]
+			i += 2
+			var value strings.Builder
+			for i < len(s) && s[i] != '"' {
+				if s[i] == '\\' && i+1 < len(s) && strings.IndexByte(`"\]`, s[i+1]) >= 0 {
+					i++
+				}
+				value.WriteByte(s[i])
+				i++
+			}
+			if i == len(s) {
+				return nil, 0, false
+			}
+			i++
+			params[name] = value.String()
+		}
+		if i == len(s) || s[i] != ']' {
+			return nil, 0, false
+		}
+		i++
+	}
+	return sd, i, i > 0
+}
+
+// decode3164 reads "Mmm dd hh:mm:ss HOSTNAME TAG[PID]: MSG". Anything that
+// doesn't follow it is kept as the message.
+func (d syslogDecoder) decode3164(rest string, m map[string]any) {
+	if len(rest) > len(time.Stamp) && rest[len(time.Stamp)] == ' ' {
+		if t, err := time.ParseInLocation(time.Stamp, rest[:len(time.Stamp)], time.Local); err == nil {
+			now := d.now()
+			t = t.AddDate(now.Year(), 0, 0)
+			// entries of late december read early january
+			if t.After(now.Add(24 * time.Hour)) {
+				t = t.AddDate(-1, 0, 0)
+			}
+			m[SyslogTimestamp] = t.Format(time.RFC3339)
+			rest = rest[len(time.Stamp)+1:]
+			if i := strings.IndexByte(rest, ' '); i > 0 {
+				m[SyslogHost] = rest[:i]
+				rest = rest[i+1:]
+			}
+		}
+	}
+	if i := strings.IndexAny(rest, ":[ "); i > 0 && i <= 48 && rest[i] != ' ' {
+		app := rest[:i]
+		after := rest[i:]
+		if after[0] == '[' {
+			if j := strings.IndexByte(after, ']'); j > 0 {
+				m[SyslogProcID] = after[1:j]
+				after = after[j+1:]
+			}
+		}
+		if strings.HasPrefix(after, ":") {
+			m[SyslogApp] = app
+			rest = strings.TrimPrefix(after[1:], " ")
+		} else {
+			delete(m, SyslogProcID)
+		}
+	}
+	m[SyslogMessage] = rest
+}
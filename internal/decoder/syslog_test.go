@@ -0,0 +1,124 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package decoder
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSyslog_Decode(t *testing.T) {
+	now := time.Date(2024, 1, 2, 10, 0, 0, 0, time.Local)
+	tests := []struct {
+		name      string
+		givenLine string
+		wantsMap  map[string]any
+		wantsOk   bool
+	}{
+		{
+			name:      "RFC 5424 with structured data",
+			givenLine: `<165>1 2003-10-11T22:14:15.003Z mymachine.example.com evntslog - ID47 [exampleSDID@32473 iut="3" eventSource="Application"][meta x="a \"quoted\\ \] value"] An application event`,
+			wantsMap: map[string]any{
+				SyslogFacility:  "local4",
+				SyslogSeverity:  "NOTICE",
+				SyslogTimestamp: "2003-10-11T22:14:15.003Z",
+				SyslogHost:      "mymachine.example.com",
+				SyslogApp:       "evntslog",
+				SyslogMsgID:     "ID47",
+				SyslogData: map[string]any{
+					"exampleSDID@32473": map[string]any{"iut": "3", "eventSource": "Application"},
+					"meta":              map[string]any{"x": `a "quoted\ ] value`},
+				},
+				SyslogMessage: "An application event",
+			},
+			wantsOk: true,
+		},
+		{
+			name:      "RFC 5424 without structured data nor message",
+			givenLine: `<34>1 2003-10-11T22:14:15.003Z host su 230 - -`,
+			wantsMap: map[string]any{
+				SyslogFacility:  "auth",
+				SyslogSeverity:  "CRIT",
+				SyslogTimestamp: "2003-10-11T22:14:15.003Z",
+				SyslogHost:      "host",
+				SyslogApp:       "su",
+				SyslogProcID:    "230",
+				SyslogMessage:   "",
+			},
+			wantsOk: true,
+		},
+		{
+			name:      "RFC 3164",
+			givenLine: `<34>Oct 11 22:14:15 mymachine su[230]: 'su root' failed for lonvick on /dev/pts/8`,
+			wantsMap: map[string]any{
+				SyslogFacility:  "auth",
+				SyslogSeverity:  "CRIT",
+				SyslogTimestamp: time.Date(2023, 10, 11, 22, 14, 15, 0, time.Local).Format(time.RFC3339),
+				SyslogHost:      "mymachine",
+				SyslogApp:       "su",
+				SyslogProcID:    "230",
+				SyslogMessage:   "'su root' failed for lonvick on /dev/pts/8",
+			},
+			wantsOk: true,
+		},
+		{
+			name:      "RFC 3164 of this year without a tag",
+			givenLine: `<13>Jan  2 09:00:00 router link up on port 3`,
+			wantsMap: map[string]any{
+				SyslogFacility:  "user",
+				SyslogSeverity:  "NOTICE",
+				SyslogTimestamp: time.Date(2024, 1, 2, 9, 0, 0, 0, time.Local).Format(time.RFC3339),
+				SyslogHost:      "router",
+				SyslogMessage:   "link up on port 3",
+			},
+			wantsOk: true,
+		},
+		{
+			name:      "Priority only",
+			givenLine: `<0>kernel panic`,
+			wantsMap: map[string]any{
+				SyslogFacility: "kern",
+				SyslogSeverity: "EMERG",
+				SyslogMessage:  "kernel panic",
+			},
+			wantsOk: true,
+		},
+		{
+			name:      "Not syslog",
+			givenLine: `{"msg":"json"}`,
+		},
+		{
+			name:      "Priority out of range",
+			givenLine: `<192>message`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			m, ok := syslogDecoder{now: func() time.Time { return now }}.Decode(test.givenLine)
+			assert.Equal(t, test.wantsOk, ok)
+			assert.Equal(t, test.wantsMap, m)
+		})
+	}
+}
@@ -0,0 +1,270 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package docker
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultHost is the Docker Engine API socket used when neither a host nor
+// $DOCKER_HOST are given.
+const DefaultHost = "unix:///var/run/docker.sock"
+
+// Container is the subset of a container description loggo relies on.
+type Container struct {
+	ID     string
+	Name   string
+	Labels map[string]string
+	TTY    bool
+	// StartedAt tells the runs of a restarted container apart, as it keeps
+	// its id.
+	StartedAt time.Time
+}
+
+// Event is a container start, as reported by the Docker Engine API.
+type Event struct {
+	ID   string
+	Time time.Time
+}
+
+// Client talks to the Docker Engine API over its socket (or tcp).
+type Client struct {
+	http *http.Client
+	base string
+}
+
+// NewClient builds a client for host, e.g. "unix:///var/run/docker.sock" or
+// "tcp://127.0.0.1:2375", defaulting to $DOCKER_HOST and then DefaultHost.
+func NewClient(host string) (*Client, error) {
+	if len(host) == 0 {
+		host = os.Getenv("DOCKER_HOST")
+	}
+	if len(host) == 0 {
+		host = DefaultHost
+	}
+	u, err := url.Parse(host)
+	if err != nil {
+		return nil, fmt.Errorf("bad docker host %s: %w", host, err)
+	}
+	switch u.Scheme {
+	case "unix":
+		socket := u.Path
+		return &Client{
+			http: &http.Client{Transport: &http.Transport{
+				DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+					var d net.Dialer
+					return d.DialContext(ctx, "unix", socket)
+				},
+			}},
+			base: "http://docker",
+		}, nil
+	case "tcp", "http":
+		return &Client{http: &http.Client{}, base: "http://" + u.Host}, nil
+	}
+	return nil, fmt.Errorf("unsupported docker host %s", host)
+}
+
+func (c *Client) get(ctx context.Context, path string, query url.Values) (*http.Response, error) {
+	u := c.base + path
+	if len(query) > 0 {
+		u += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	res, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode != http.StatusOK {
+		defer res.Body.Close()
+		var msg struct {
+			Message string `json:"message"`
+		}
+		_ = json.NewDecoder(res.Body).Decode(&msg)
+		return nil, fmt.Errorf("docker %s: %s %s", path, res.Status, msg.Message)
+	}
+	return res, nil
+}
+
+func (c *Client) getJSON(ctx context.Context, path string, query url.Values, v any) error {
+	res, err := c.get(ctx, path, query)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	return json.NewDecoder(res.Body).Decode(v)
+}
+
+// filters encodes the filters query parameter of the Engine API.
+func filters(f map[string][]string) url.Values {
+	b, _ := json.Marshal(f)
+	return url.Values{"filters": {string(b)}}
+}
+
+// Containers lists the running containers carrying every one of labels
+// ("key" or "key=value").
+func (c *Client) Containers(ctx context.Context, labels []string) ([]Container, error) {
+	var query url.Values
+	if len(labels) > 0 {
+		query = filters(map[string][]string{"label": labels})
+	}
+	var list []struct {
+		ID     string            `json:"Id"`
+		Names  []string          `json:"Names"`
+		Labels map[string]string `json:"Labels"`
+	}
+	if err := c.getJSON(ctx, "/containers/json", query, &list); err != nil {
+		return nil, err
+	}
+	containers := make([]Container, 0, len(list))
+	for _, l := range list {
+		// the tty setting is only part of the inspection
+		ct, err := c.Inspect(ctx, l.ID)
+		if err != nil {
+			return nil, err
+		}
+		containers = append(containers, ct)
+	}
+	return containers, nil
+}
+
+// Inspect describes a single container.
+func (c *Client) Inspect(ctx context.Context, id string) (Container, error) {
+	var info struct {
+		ID     string `json:"Id"`
+		Name   string `json:"Name"`
+		Config struct {
+			Labels map[string]string `json:"Labels"`
+			TTY    bool              `json:"Tty"`
+		} `json:"Config"`
+		State struct {
+			StartedAt time.Time `json:"StartedAt"`
+		} `json:"State"`
+	}
+	if err := c.getJSON(ctx, "/containers/"+url.PathEscape(id)+"/json", nil, &info); err != nil {
+		return Container{}, err
+	}
+	return Container{
+		ID:        info.ID,
+		Name:      strings.TrimPrefix(info.Name, "/"),
+		Labels:    info.Config.Labels,
+		TTY:       info.Config.TTY,
+		StartedAt: info.State.StartedAt,
+	}, nil
+}
+
+// Logs streams the output of a container from since (the whole log if zero),
+// following it until the container stops. Unless the container has a tty,
+// stdout and stderr come multiplexed, see Demux.
+func (c *Client) Logs(ctx context.Context, id string, since time.Time) (io.ReadCloser, error) {
+	query := url.Values{
+		"follow": {"1"},
+		"stdout": {"1"},
+		"stderr": {"1"},
+	}
+	if !since.IsZero() {
+		query.Set("since", strconv.FormatInt(since.Unix(), 10))
+	}
+	res, err := c.get(ctx, "/containers/"+url.PathEscape(id)+"/logs", query)
+	if err != nil {
+		return nil, err
+	}
+	return res.Body, nil
+}
+
+// Starts streams the containers carrying every one of labels started from now
+// on into events, until ctx is done or the connection drops.
+func (c *Client) Starts(ctx context.Context, labels []string, events chan<- Event) error {
+	f := map[string][]string{
+		"type":  {"container"},
+		"event": {"start"},
+	}
+	if len(labels) > 0 {
+		f["label"] = labels
+	}
+	res, err := c.get(ctx, "/events", filters(f))
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	dec := json.NewDecoder(bufio.NewReader(res.Body))
+	for {
+		var ev struct {
+			ID       string `json:"id"`
+			TimeNano int64  `json:"timeNano"`
+		}
+		if err := dec.Decode(&ev); err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return err
+		}
+		select {
+		case events <- Event{ID: ev.ID, Time: time.Unix(0, ev.TimeNano)}:
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// Demux splits the multiplexed output of a container without a tty into
+// stdout and stderr. Each frame has an 8 bytes header: the stream (1 for
+// stdout, 2 for stderr), 3 bytes of padding and the size of the payload.
+func Demux(r io.Reader, stdout, stderr io.Writer) error {
+	header := make([]byte, 8)
+	for {
+		if _, err := io.ReadFull(r, header); err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		var w io.Writer
+		switch header[0] {
+		case 0, 1:
+			w = stdout
+		case 2:
+			w = stderr
+		default:
+			return fmt.Errorf("unexpected stream %d in docker log", header[0])
+		}
+		size := int64(binary.BigEndian.Uint32(header[4:]))
+		if _, err := io.CopyN(w, r, size); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,126 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package docker
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// frame multiplexes payload as the Engine API does for stream (1 stdout, 2
+// stderr).
+func frame(stream byte, payload string) []byte {
+	header := make([]byte, 8)
+	header[0] = stream
+	binary.BigEndian.PutUint32(header[4:], uint32(len(payload)))
+	return append(header, payload...)
+}
+
+func TestDemux(t *testing.T) {
+	tests := []struct {
+		name       string
+		givenInput []byte
+		wantStdout string
+		wantStderr string
+		wantError  bool
+	}{
+		{
+			name: "Splits stdout and stderr",
+			givenInput: bytes.Join([][]byte{
+				frame(1, "out 1\n"),
+				frame(2, "err 1\n"),
+				frame(1, "out "),
+				frame(1, "2\n"),
+			}, nil),
+			wantStdout: "out 1\nout 2\n",
+			wantStderr: "err 1\n",
+		},
+		{
+			name:       "Empty",
+			givenInput: nil,
+		},
+		{
+			name:       "Truncated frame",
+			givenInput: frame(1, "out 1\n")[:10],
+			wantStdout: "ou",
+			wantError:  true,
+		},
+		{
+			name:       "Unknown stream",
+			givenInput: frame(7, "out 1\n"),
+			wantError:  true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			err := Demux(bytes.NewReader(test.givenInput), &stdout, &stderr)
+			if test.wantError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, test.wantStdout, stdout.String())
+			assert.Equal(t, test.wantStderr, stderr.String())
+		})
+	}
+}
+
+func TestNewClient(t *testing.T) {
+	tests := []struct {
+		name      string
+		givenHost string
+		wantBase  string
+		wantError bool
+	}{
+		{
+			name:      "Unix socket",
+			givenHost: "unix:///var/run/docker.sock",
+			wantBase:  "http://docker",
+		},
+		{
+			name:      "Tcp",
+			givenHost: "tcp://127.0.0.1:2375",
+			wantBase:  "http://127.0.0.1:2375",
+		},
+		{
+			name:      "Unsupported",
+			givenHost: "ssh://me@host",
+			wantError: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c, err := NewClient(test.givenHost)
+			if test.wantError {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, test.wantBase, c.base)
+		})
+	}
+}
@@ -0,0 +1,161 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package export
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"iter"
+	"path/filepath"
+	"strings"
+
+	"github.com/aurc/loggo/internal/config"
+)
+
+// Format is the output format of an export.
+type Format string
+
+const (
+	JSONL    Format = "jsonl"
+	CSV      Format = "csv"
+	Markdown Format = "md"
+)
+
+// Formats lists the supported formats, in the order they're offered.
+func Formats() []Format {
+	return []Format{JSONL, CSV, Markdown}
+}
+
+// Label is the human friendly name of the format.
+func (f Format) Label() string {
+	switch f {
+	case CSV:
+		return "CSV"
+	case Markdown:
+		return "Markdown Table"
+	default:
+		return "JSON Lines"
+	}
+}
+
+// WithExt replaces the extension of fileName with the format's own.
+func (f Format) WithExt(fileName string) string {
+	return strings.TrimSuffix(fileName, filepath.Ext(fileName)) + "." + string(f)
+}
+
+// Write writes every entry to w in the given format and returns the number of
+// entries written. JSONL keeps the entries as they were decoded, or the line
+// read as a JSON string for those that couldn't be, while CSV and Markdown
+// render one column per template key.
+func Write(w io.Writer, f Format, keys []config.Key, entries iter.Seq[map[string]any]) (int, error) {
+	switch f {
+	case JSONL:
+		return writeJSONL(w, entries)
+	case CSV:
+		return writeCSV(w, keys, entries)
+	case Markdown:
+		return writeMarkdown(w, keys, entries)
+	}
+	return 0, fmt.Errorf("unsupported export format %q", f)
+}
+
+func writeJSONL(w io.Writer, entries iter.Seq[map[string]any]) (int, error) {
+	count := 0
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	for m := range entries {
+		var v any = m
+		if _, unparsed := m[config.ParseErr]; unparsed {
+			v = m[config.TextPayload]
+		}
+		if err := enc.Encode(v); err != nil {
+			return count, err
+		}
+		count++
+	}
+	return count, nil
+}
+
+func writeCSV(w io.Writer, keys []config.Key, entries iter.Seq[map[string]any]) (int, error) {
+	count := 0
+	cw := csv.NewWriter(w)
+	if err := cw.Write(header(keys)); err != nil {
+		return count, err
+	}
+	for m := range entries {
+		if err := cw.Write(values(keys, m)); err != nil {
+			return count, err
+		}
+		count++
+	}
+	cw.Flush()
+	return count, cw.Error()
+}
+
+func writeMarkdown(w io.Writer, keys []config.Key, entries iter.Seq[map[string]any]) (int, error) {
+	count := 0
+	row := func(cells []string) error {
+		for i, c := range cells {
+			cells[i] = mdReplacer.Replace(c)
+		}
+		_, err := fmt.Fprintf(w, "| %s |\n", strings.Join(cells, " | "))
+		return err
+	}
+	if err := row(header(keys)); err != nil {
+		return count, err
+	}
+	sep := make([]string, len(keys))
+	for i := range sep {
+		sep[i] = "---"
+	}
+	if err := row(sep); err != nil {
+		return count, err
+	}
+	for m := range entries {
+		if err := row(values(keys, m)); err != nil {
+			return count, err
+		}
+		count++
+	}
+	return count, nil
+}
+
+var mdReplacer = strings.NewReplacer(`|`, `\|`, "\r\n", "<br>", "\n", "<br>")
+
+func header(keys []config.Key) []string {
+	h := make([]string, len(keys))
+	for i, k := range keys {
+		h[i] = k.Name
+	}
+	return h
+}
+
+func values(keys []config.Key, m map[string]any) []string {
+	v := make([]string, len(keys))
+	for i := range keys {
+		v[i] = keys[i].ExtractValue(m)
+	}
+	return v
+}
@@ -0,0 +1,103 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package export
+
+import (
+	"bytes"
+	"slices"
+	"testing"
+
+	"github.com/aurc/loggo/internal/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWrite(t *testing.T) {
+	keys := []config.Key{
+		{Name: "level"},
+		{Name: "message"},
+		{Name: "http/status"},
+	}
+	entries := []map[string]any{
+		{"level": "info", "message": "hello, world", "http": map[string]any{"status": float64(200)}},
+		{"level": "error", "message": "a | b\nnext line"},
+		{config.ParseErr: "unrecognised log format", config.TextPayload: "plain text"},
+	}
+	tests := []struct {
+		name      string
+		format    Format
+		wantsText string
+		wantsErr  bool
+	}{
+		{
+			name:   "jsonl",
+			format: JSONL,
+			wantsText: `{"http":{"status":200},"level":"info","message":"hello, world"}
+{"level":"error","message":"a | b\nnext line"}
+"plain text"
+`,
+		},
+		{
+			name:   "csv",
+			format: CSV,
+			wantsText: `level,message,http/status
+info,"hello, world",200
+error,"a | b
+next line",
+,plain text,
+`,
+		},
+		{
+			name:   "markdown",
+			format: Markdown,
+			wantsText: `| level | message | http/status |
+| --- | --- | --- |
+| info | hello, world | 200 |
+| error | a \| b<br>next line |  |
+|  | plain text |  |
+`,
+		},
+		{
+			name:     "unknown format",
+			format:   Format("xml"),
+			wantsErr: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			buf := &bytes.Buffer{}
+			n, err := Write(buf, test.format, keys, slices.Values(entries))
+			if test.wantsErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, len(entries), n)
+			assert.Equal(t, test.wantsText, buf.String())
+		})
+	}
+}
+
+func TestFormat_WithExt(t *testing.T) {
+	assert.Equal(t, "/tmp/out.csv", CSV.WithExt("/tmp/out.jsonl"))
+	assert.Equal(t, "out.md", Markdown.WithExt("out"))
+}
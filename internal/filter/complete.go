@@ -0,0 +1,152 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package filter
+
+import (
+	"strings"
+)
+
+// Completion describes the token being typed at the end of an expression.
+type Completion struct {
+	// Prefix is the text preceding the token being completed.
+	Prefix string
+	// Partial is the token being completed, possibly empty.
+	Partial string
+	// Key is set when a value is being typed, to the key it's compared with.
+	Key string
+}
+
+// IsValue tells whether the token being completed is a value.
+func (c Completion) IsValue() bool {
+	return len(c.Key) > 0
+}
+
+type completionToken struct {
+	text  string
+	start int
+	word  bool
+}
+
+var valueOperators = map[string]bool{
+	"=": true, "==": true, "!=": true, "<>": true, "<": true, "<=": true, ">": true, ">=": true,
+	"CONTAINS": true, "CONTAINSIC": true, "MATCH": true,
+}
+
+// Complete works out what's being typed at the end of expression: a key, or
+// the value of a comparison (key = '...') or IN list (key IN ('a', '...').
+func Complete(expression string) Completion {
+	tokens := completionTokens(expression)
+	c := Completion{Prefix: expression}
+	if n := len(tokens); n > 0 && tokens[n-1].word &&
+		tokens[n-1].start+len(tokens[n-1].text) == len(expression) {
+		c.Prefix = expression[:tokens[n-1].start]
+		c.Partial = tokens[n-1].text
+		tokens = tokens[:n-1]
+	}
+	c.Key = valueKey(tokens)
+	return c
+}
+
+// valueKey returns the key whose value follows the given tokens, if any.
+func valueKey(tokens []completionToken) string {
+	n := len(tokens)
+	if n == 0 {
+		return ""
+	}
+	keyBefore := func(i int) string {
+		if i > 0 && strings.EqualFold(tokens[i-1].text, "NOT") {
+			i--
+		}
+		if i > 0 && tokens[i-1].word && !isQuoted(tokens[i-1].text) {
+			return tokens[i-1].text
+		}
+		return ""
+	}
+	last := tokens[n-1].text
+	if valueOperators[strings.ToUpper(last)] {
+		return keyBefore(n - 1)
+	}
+	if last != "(" && last != "," {
+		return ""
+	}
+	// inside an IN list: walk back to its opening parenthesis
+	i := n - 1
+	for i >= 0 && tokens[i].text != "(" {
+		if tokens[i].text != "," && !tokens[i].word {
+			return ""
+		}
+		i--
+	}
+	if i > 0 && strings.EqualFold(tokens[i-1].text, "IN") {
+		return keyBefore(i - 1)
+	}
+	return ""
+}
+
+func completionTokens(expression string) []completionToken {
+	var tokens []completionToken
+	for i := 0; i < len(expression); {
+		ch := expression[i]
+		switch {
+		case ch == ' ' || ch == '\t':
+			i++
+		case ch == '\'' || ch == '"':
+			end := strings.IndexByte(expression[i+1:], ch)
+			if end < 0 {
+				end = len(expression)
+			} else {
+				end += i + 2
+			}
+			tokens = append(tokens, completionToken{text: expression[i:end], start: i, word: true})
+			i = end
+		case strings.IndexByte("()", ch) >= 0 || ch == ',':
+			tokens = append(tokens, completionToken{text: string(ch), start: i})
+			i++
+		case strings.IndexByte("<>=!", ch) >= 0:
+			start := i
+			for i < len(expression) && strings.IndexByte("<>=!", expression[i]) >= 0 {
+				i++
+			}
+			tokens = append(tokens, completionToken{text: expression[start:i], start: start})
+		default:
+			start := i
+			for i < len(expression) && strings.IndexByte(" \t'\"(),<>=!", expression[i]) < 0 {
+				i++
+			}
+			tokens = append(tokens, completionToken{text: expression[start:i], start: start, word: true})
+		}
+	}
+	return tokens
+}
+
+func isQuoted(s string) bool {
+	return strings.HasPrefix(s, "'") || strings.HasPrefix(s, `"`)
+}
+
+// QuoteValue renders a value so it can be typed into an expression.
+func QuoteValue(value string) string {
+	if strings.Contains(value, "'") {
+		return `"` + value + `"`
+	}
+	return "'" + value + "'"
+}
@@ -0,0 +1,91 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package filter
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestComplete(t *testing.T) {
+	tests := []struct {
+		given string
+		wants Completion
+	}{
+		{
+			given: ``,
+			wants: Completion{},
+		},
+		{
+			given: `reso`,
+			wants: Completion{Partial: `reso`},
+		},
+		{
+			given: `level = 'error' AND resource/lab`,
+			wants: Completion{Prefix: `level = 'error' AND `, Partial: `resource/lab`},
+		},
+		{
+			given: `(level = 'error' OR `,
+			wants: Completion{Prefix: `(level = 'error' OR `},
+		},
+		{
+			given: `resource/labels/container_name = `,
+			wants: Completion{Prefix: `resource/labels/container_name = `, Key: `resource/labels/container_name`},
+		},
+		{
+			given: `level='err`,
+			wants: Completion{Prefix: `level=`, Partial: `'err`, Key: `level`},
+		},
+		{
+			given: `msg not contains "time`,
+			wants: Completion{Prefix: `msg not contains `, Partial: `"time`, Key: `msg`},
+		},
+		{
+			given: `level IN ('error', wa`,
+			wants: Completion{Prefix: `level IN ('error', `, Partial: `wa`, Key: `level`},
+		},
+		{
+			given: `level NOT IN (`,
+			wants: Completion{Prefix: `level NOT IN (`, Key: `level`},
+		},
+		{
+			given: `level = 'error' `,
+			wants: Completion{Prefix: `level = 'error' `},
+		},
+		{
+			given: `(level = 'error' OR (`,
+			wants: Completion{Prefix: `(level = 'error' OR (`},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.given, func(t *testing.T) {
+			assert.Equal(t, test.wants, Complete(test.given))
+		})
+	}
+}
+
+func TestQuoteValue(t *testing.T) {
+	assert.Equal(t, `'error'`, QuoteValue("error"))
+	assert.Equal(t, `"it's"`, QuoteValue("it's"))
+}
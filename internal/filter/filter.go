@@ -23,6 +23,7 @@ THE SOFTWARE.
 package filter
 
 import (
+	"fmt"
 	"regexp"
 	"strconv"
 	"strings"
@@ -47,6 +48,8 @@ const (
 	OpMatchesRegex       = Operation("OpMatchesRegex")
 	OpBetween            = Operation("OpBetween")
 	OpBetweenInclusive   = Operation("OpBetweenInclusive")
+	OpLast               = Operation("OpLast")
+	OpIn                 = Operation("OpIn")
 )
 
 type Filter interface {
@@ -198,6 +201,26 @@ func BetweenInclusive(key string, expression, expression2 string) *betweenInclus
 	}
 }
 
+func Last(key string, duration string) *last {
+	return &last{
+		Predicate: Predicate{
+			KeyName:       key,
+			KeyExpression: []string{duration},
+			Operation:     OpLast,
+		},
+	}
+}
+
+func In(key string, expressions ...string) *in {
+	return &in{
+		Predicate: Predicate{
+			KeyName:       key,
+			KeyExpression: expressions,
+			Operation:     OpIn,
+		},
+	}
+}
+
 type equals struct {
 	Predicate
 }
@@ -452,6 +475,43 @@ func (f *betweenInclusive) Apply(value string, key map[string]*config.Key) (bool
 	return false, nil
 }
 
+type in struct {
+	Predicate
+}
+
+func (f *in) Apply(value string, key map[string]*config.Key) (bool, error) {
+	for _, e := range f.KeyExpression {
+		v, err := EqualIgnoreCase(f.KeyName, e).Apply(value, key)
+		if err != nil || v {
+			return v, err
+		}
+	}
+	return false, nil
+}
+
+type last struct {
+	Predicate
+}
+
+func (f *last) Apply(value string, key map[string]*config.Key) (bool, error) {
+	k, ok := key[f.KeyName]
+	if !ok || k.Type != config.TypeDateTime {
+		return false, fmt.Errorf("LAST requires %s to be a datetime key", f.KeyName)
+	}
+	d, err := parseDuration(f.KeyExpression[0])
+	if err != nil {
+		return false, err
+	}
+	if len(strings.TrimSpace(value)) == 0 {
+		return false, nil
+	}
+	v, err := parseLogTime(k, value)
+	if err != nil {
+		return false, err
+	}
+	return !v.Before(timeNow().Add(-d)), nil
+}
+
 func (p *Predicate) parseNumberAndCheck(value string, check func(number, expression float64) (bool, error)) (bool, error) {
 	var n, e float64
 	var err error
@@ -505,9 +565,12 @@ func (p *Predicate) parseBoolAndCheck(value string, check func(value, expression
 func (p *Predicate) parseDateTimeAndCheck(value string, key *config.Key, check func(value, expression time.Time) (bool, error)) (bool, error) {
 	var v, e time.Time
 	var err error
-	v, err = time.Parse(key.Layout, value)
+	if len(strings.TrimSpace(value)) == 0 {
+		return false, nil
+	}
+	v, err = parseLogTime(key, value)
 	if err == nil {
-		e, err = time.Parse(key.Layout, p.KeyExpression[0])
+		e, err = parseTimeExpression(key, p.KeyExpression[0])
 		if err == nil {
 			return check(v, e)
 		}
@@ -518,11 +581,14 @@ func (p *Predicate) parseDateTimeAndCheck(value string, key *config.Key, check f
 func (f *between) parseDateTimeAndCheck(value string, key *config.Key, check func(value, expression, expression2 time.Time) (bool, error)) (bool, error) {
 	var v, e, e2 time.Time
 	var err error
-	v, err = time.Parse(key.Layout, value)
+	if len(strings.TrimSpace(value)) == 0 {
+		return false, nil
+	}
+	v, err = parseLogTime(key, value)
 	if err == nil {
-		e, err = time.Parse(key.Layout, f.KeyExpression[0])
+		e, err = parseTimeExpression(key, f.KeyExpression[0])
 		if err == nil {
-			e2, err = time.Parse(key.Layout, f.KeyExpression[1])
+			e2, err = parseTimeExpression(key, f.KeyExpression[1])
 			if err == nil {
 				return check(v, e, e2)
 			}
@@ -0,0 +1,101 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package filter
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const (
+	historyFile       = "filter_history"
+	defaultMaxHistory = 100
+)
+
+// History keeps the filter expressions applied, oldest first, in a file so
+// they can be recalled across sessions.
+type History struct {
+	file    string
+	max     int
+	entries []string
+}
+
+// NewHistory loads the history kept in the given file, if any.
+func NewHistory(file string) *History {
+	h := &History{file: file, max: defaultMaxHistory}
+	f, err := os.Open(file)
+	if err != nil {
+		return h
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); len(line) > 0 {
+			h.entries = append(h.entries, line)
+		}
+	}
+	h.trim()
+	return h
+}
+
+// DefaultHistory is the history kept under ~/.loggo/filter_history.
+func DefaultHistory() (*History, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil, err
+	}
+	return NewHistory(filepath.Join(home, parentPath, historyFile)), nil
+}
+
+// Entries returns the expressions, oldest first.
+func (h *History) Entries() []string {
+	return h.entries
+}
+
+// Add records the expression as the most recent one and persists the history.
+func (h *History) Add(expression string) error {
+	expression = strings.TrimSpace(expression)
+	if len(expression) == 0 {
+		return nil
+	}
+	entries := h.entries[:0]
+	for _, e := range h.entries {
+		if e != expression {
+			entries = append(entries, e)
+		}
+	}
+	h.entries = append(entries, expression)
+	h.trim()
+	if err := os.MkdirAll(filepath.Dir(h.file), os.ModePerm); err != nil {
+		return err
+	}
+	return os.WriteFile(h.file, []byte(strings.Join(h.entries, "\n")+"\n"), 0644)
+}
+
+func (h *History) trim() {
+	if len(h.entries) > h.max {
+		h.entries = h.entries[len(h.entries)-h.max:]
+	}
+}
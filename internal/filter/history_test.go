@@ -0,0 +1,51 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package filter
+
+import (
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHistory(t *testing.T) {
+	file := filepath.Join(t.TempDir(), "loggo", "history")
+	h := NewHistory(file)
+	assert.Empty(t, h.Entries())
+
+	assert.NoError(t, h.Add(`level = "error"`))
+	assert.NoError(t, h.Add(` msg = "a  b" `))
+	assert.NoError(t, h.Add(`level = "error"`))
+	assert.NoError(t, h.Add(`   `))
+	assert.Equal(t, []string{`msg = "a  b"`, `level = "error"`}, h.Entries())
+
+	assert.Equal(t, h.Entries(), NewHistory(file).Entries())
+
+	h.max = 3
+	for i := 0; i < 5; i++ {
+		assert.NoError(t, h.Add(fmt.Sprintf(`n = %d`, i)))
+	}
+	assert.Equal(t, []string{`n = 2`, `n = 3`, `n = 4`}, NewHistory(file).Entries())
+}
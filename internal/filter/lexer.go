@@ -25,7 +25,10 @@ package filter
 import (
 	"encoding/json"
 	"fmt"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
 
 	"github.com/alecthomas/participle/v2"
 	"github.com/alecthomas/participle/v2/lexer"
@@ -40,16 +43,23 @@ const (
 )
 
 var (
+	// Key paths (with a / or .) are lexed before keywords so that e.g. in/x or
+	// not.level aren't taken for IN or NOT. A bare key named after a keyword
+	// (in, not, last, now...) can't be filtered on.
 	sqlLexer = lexer.MustSimple([]lexer.SimpleRule{
-		{Name: `Keyword`, Pattern: `(?i)\b(MATCH|CONTAINSIC|CONTAINS|BETWEEN|AND|OR)\b`},
-		{Name: `Ident`, Pattern: `[a-zA-Z_][a-zA-Z0-9_./]*`},
+		{Name: `Path`, Pattern: `[$a-zA-Z_][a-zA-Z0-9_]*[./][a-zA-Z0-9_./]*`},
+		{Name: `Keyword`, Pattern: `(?i)\b(MATCH|CONTAINSIC|CONTAINS|BETWEEN|LAST|EXISTS|MISSING|NOT|IN|AND|OR)\b`},
+		{Name: `Relative`, Pattern: `(?i)\bnow\b(\s*[-+]\s*(\d+(\.\d+)?(ms|s|m|h|d|w))+)?`},
+		{Name: `Ident`, Pattern: `[$a-zA-Z_][a-zA-Z0-9_./]*`},
+		{Name: `Duration`, Pattern: `(?i)(\d+(\.\d+)?(ms|s|m|h|d|w))+\b`},
 		{Name: `Number`, Pattern: `[-+]?\d*\.?\d+([eE][-+]?\d+)?`},
 		{Name: `String`, Pattern: `'[^']*'|"[^"]*"`},
-		{Name: `Operators`, Pattern: `<>|!=|<=|>=|==|[()=<>]`},
+		{Name: `Operators`, Pattern: `<>|!=|<=|>=|==|[(),=<>]`},
 		{Name: "whitespace", Pattern: `\s+`},
 	})
 
-	cachedDef = make(map[string]Filter)
+	cachedDef     = make(map[string]Filter)
+	cachedDefLock sync.RWMutex
 
 	parser = participle.MustBuild[Expression](
 		participle.Lexer(sqlLexer),
@@ -63,11 +73,13 @@ func ParseFilterExpression(exp string) (*Expression, error) {
 }
 
 func cachedOperation(op Operation, key string, v ...string) Filter {
-	ck := fmt.Sprintf(`[%s:%s]:%+v`, op, key, v)
-	if v, ok := cachedDef[ck]; ok {
-		return v
+	ck := fmt.Sprintf(`[%s:%q]:%q`, op, key, v)
+	cachedDefLock.RLock()
+	f, ok := cachedDef[ck]
+	cachedDefLock.RUnlock()
+	if ok {
+		return f
 	}
-	var f Filter
 	switch op {
 	case OpNotEqual:
 		f = NotEquals(key, v[0])
@@ -91,8 +103,14 @@ func cachedOperation(op Operation, key string, v ...string) Filter {
 		f = MatchesRegex(key, v[0])
 	case OpBetween:
 		f = BetweenInclusive(key, v[0], v[1])
+	case OpLast:
+		f = Last(key, v[0])
+	case OpIn:
+		f = In(key, v...)
 	}
+	cachedDefLock.Lock()
 	cachedDef[ck] = f
+	cachedDefLock.Unlock()
 	return f
 }
 
@@ -109,33 +127,60 @@ type Expression struct {
 }
 
 type ConditionElement struct {
-	Condition     *Condition   ` @@`
-	GlobalToken   *GlobalToken `| @@ `
-	Subexpression *Expression  `| "(" @@ ")"`
+	Not           *ConditionElement `  "NOT" @@`
+	Exists        *ExistsToken      `| @@`
+	Last          *LastToken        `| @@`
+	Condition     *Condition        `| @@`
+	GlobalToken   *GlobalToken      `| @@ `
+	Subexpression *Expression       `| "(" @@ ")"`
 }
 
 type GlobalToken struct {
 	String *string `@String`
 }
 
+// LastToken keeps entries logged within the duration (e.g. LAST 5m), using
+// the template's datetime key.
+type LastToken struct {
+	Duration string `"LAST" @Duration`
+}
+
+// ExistsToken checks whether an entry has (EXISTS) or lacks (MISSING) a key.
+type ExistsToken struct {
+	Operator string `@( "EXISTS" | "MISSING" )`
+	Key      string `@( Ident | Path )`
+}
+
 type Condition struct {
-	Operand  string `@Ident`
-	Operator string `@( "<>" | "<=" | ">=" | "=" | "==" | "<" | ">" | "!=" | "BETWEEN" | "CONTAINS" | "CONTAINSIC" | "MATCH" )`
-	Value    *Value `@@`
-	Value2   *Value `( "AND" @@ )*`
+	Operand  string   `@( Ident | Path )`
+	Negated  bool     `@"NOT"?`
+	Operator string   `@( "<>" | "<=" | ">=" | "=" | "==" | "<" | ">" | "!=" | "BETWEEN" | "CONTAINS" | "CONTAINSIC" | "MATCH" | "LAST" | "IN" )`
+	Values   []*Value `( "(" @@ ( "," @@ )* ")"`
+	Value    *Value   `| @@`
+	Value2   *Value   `( "AND" @@ )* )`
 }
 
+// ToString returns the literal as compared to the entries. Numbers take their
+// shortest form (200, not 200.000000) so they also match values of keys that
+// aren't typed as numbers.
 func (v *Value) ToString() string {
-	if v.Number == nil {
+	switch {
+	case v.Number != nil:
+		return strconv.FormatFloat(*v.Number, 'f', -1, 64)
+	case v.Relative != nil:
+		return *v.Relative
+	case v.Duration != nil:
+		return *v.Duration
+	default:
 		return *v.String
-	} else {
-		return fmt.Sprintf(`%f`, *v.Number)
 	}
 }
 
 type Value struct {
-	Number *float64 `( @Number`
-	String *string  ` | @String )`
+	Number   *float64 `( @Number`
+	String   *string  ` | @String`
+	Relative *string  ` | @Relative`
+	Duration *string  ` | @Duration )`
 }
 
 type OpValue struct {
@@ -165,6 +210,13 @@ func (c LogicalOperator) Apply(l, r bool) bool {
 
 func (c *ConditionElement) Apply(row map[string]any, key map[string]*config.Key) (bool, error) {
 	switch {
+	case c.Not != nil:
+		v, err := c.Not.Apply(row, key)
+		return !v, err
+	case c.Exists != nil:
+		return c.Exists.Apply(row), nil
+	case c.Last != nil:
+		return c.Last.Apply(row, key)
 	case c.Condition != nil:
 		return c.Condition.Apply(row, key)
 	case c.GlobalToken != nil:
@@ -183,6 +235,51 @@ func (g *GlobalToken) Apply(row map[string]any) (bool, error) {
 	return strings.Contains(str, strings.ToLower(*g.String)), nil
 }
 
+func (e *ExistsToken) Apply(row map[string]any) bool {
+	_, found := lookup(row, e.Key)
+	return found == strings.EqualFold(e.Operator, "EXISTS")
+}
+
+// lookup resolves a (possibly nested, slash separated) key in the entry.
+func lookup(row map[string]any, name string) (any, bool) {
+	var level any = row
+	for _, k := range strings.Split(name, "/") {
+		m, ok := level.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		if level = m[k]; level == nil {
+			return nil, false
+		}
+	}
+	return level, true
+}
+
+func (l *LastToken) Apply(row map[string]any, key map[string]*config.Key) (bool, error) {
+	name := defaultTimeKey(key)
+	if len(name) == 0 {
+		return false, fmt.Errorf("LAST requires a datetime key in the template")
+	}
+	c := &Condition{Operand: name, Operator: "LAST", Value: &Value{Duration: &l.Duration}}
+	return c.Apply(row, key)
+}
+
+// defaultTimeKey picks the datetime key LAST applies to when none is given,
+// the first by name if the template has several.
+func defaultTimeKey(key map[string]*config.Key) string {
+	var names []string
+	for n, k := range key {
+		if k.Type == config.TypeDateTime {
+			names = append(names, n)
+		}
+	}
+	if len(names) == 0 {
+		return ""
+	}
+	sort.Strings(names)
+	return names[0]
+}
+
 func (c *Condition) Apply(row map[string]any, key map[string]*config.Key) (bool, error) {
 	var op Operation
 	switch strings.ToUpper(c.Operator) {
@@ -208,14 +305,33 @@ func (c *Condition) Apply(row map[string]any, key map[string]*config.Key) (bool,
 		op = OpMatchesRegex
 	case "BETWEEN":
 		op = OpBetween
+	case "LAST":
+		op = OpLast
+	case "IN":
+		op = OpIn
 	default:
 		return false, fmt.Errorf("unrecognised operator %s", c.Operator)
 	}
-	v2 := ""
-	if c.Value2 != nil {
-		v2 = c.Value2.ToString()
+	var fi Filter
+	if op == OpIn {
+		if len(c.Values) == 0 {
+			return false, fmt.Errorf("IN requires a list of values, e.g. %s IN ('a', 'b')", c.Operand)
+		}
+		values := make([]string, len(c.Values))
+		for i, v := range c.Values {
+			values[i] = v.ToString()
+		}
+		fi = cachedOperation(op, c.Operand, values...)
+	} else {
+		if c.Value == nil {
+			return false, fmt.Errorf("%s requires a single value", c.Operator)
+		}
+		v2 := ""
+		if c.Value2 != nil {
+			v2 = c.Value2.ToString()
+		}
+		fi = cachedOperation(op, c.Operand, c.Value.ToString(), v2)
 	}
-	fi := cachedOperation(op, c.Operand, c.Value.ToString(), v2)
 	var k *config.Key
 	if v, ok := key[fi.Name()]; ok {
 		k = v
@@ -225,7 +341,11 @@ func (c *Condition) Apply(row map[string]any, key map[string]*config.Key) (bool,
 			Type: config.TypeString,
 		}
 	}
-	return fi.Apply(k.ExtractValue(row), key)
+	v, err := fi.Apply(k.ExtractValue(row), key)
+	if c.Negated {
+		v = !v
+	}
+	return v, err
 }
 
 func (c *Term) Apply(row map[string]any, key map[string]*config.Key) (bool, error) {
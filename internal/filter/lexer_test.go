@@ -24,7 +24,10 @@ package filter
 
 import (
 	"encoding/json"
+	"fmt"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/aurc/loggo/internal/config"
 	"github.com/stretchr/testify/assert"
@@ -314,6 +317,128 @@ func TestParseFilterExpression(t *testing.T) {
 			},
 			wantsResult: true,
 		},
+		{
+			name: `wants true - internal source key`,
+			whenJsonRow: `
+					{
+						"$_source": "logs/app-1.log",
+						"s": "some"
+					}`,
+			givenExpression: `$_source contains "app-1" and s = "some"`,
+			keySet:          map[string]*config.Key{},
+			wantsResult:     true,
+		},
+		{
+			name: `wants false - not excludes health checks`,
+			whenJsonRow: `
+					{
+						"path": "/healthz",
+						"status": 200
+					}`,
+			givenExpression: `NOT (path = "/healthz" OR path = "/ready") AND status = 200`,
+			keySet:          map[string]*config.Key{},
+			wantsResult:     false,
+		},
+		{
+			name: `wants true - not of a single condition`,
+			whenJsonRow: `
+					{
+						"path": "/api/users",
+						"status": 200
+					}`,
+			givenExpression: `not path contains "healthz" and not "probe"`,
+			keySet:          map[string]*config.Key{},
+			wantsResult:     true,
+		},
+		{
+			name: `wants true - not contains`,
+			whenJsonRow: `
+					{
+						"msg": "GET /api/users"
+					}`,
+			givenExpression: `msg NOT CONTAINS "/healthz"`,
+			keySet:          map[string]*config.Key{},
+			wantsResult:     true,
+		},
+		{
+			name: `wants false - not match`,
+			whenJsonRow: `
+					{
+						"msg": "GET /readyz 200"
+					}`,
+			givenExpression: `msg NOT MATCH "/(healthz|readyz)"`,
+			keySet:          map[string]*config.Key{},
+			wantsResult:     false,
+		},
+		{
+			name: `wants true - in list`,
+			whenJsonRow: `
+					{
+						"level": "Warn",
+						"status": "503"
+					}`,
+			givenExpression: `level IN ('error', 'warn') AND status IN (500, 502, 503)`,
+			keySet:          map[string]*config.Key{},
+			wantsResult:     true,
+		},
+		{
+			name: `wants true - in list of numbers`,
+			whenJsonRow: `
+					{
+						"status": 502
+					}`,
+			givenExpression: `status IN (500.0, 502)`,
+			keySet: map[string]*config.Key{
+				"status": {
+					Name: "status",
+					Type: config.TypeNumber,
+				},
+			},
+			wantsResult: true,
+		},
+		{
+			name: `wants false - not in list`,
+			whenJsonRow: `
+					{
+						"level": "info"
+					}`,
+			givenExpression: `level NOT IN ("info", "debug")`,
+			keySet:          map[string]*config.Key{},
+			wantsResult:     false,
+		},
+		{
+			name: `wants error - in without a list`,
+			whenJsonRow: `
+					{
+						"level": "info"
+					}`,
+			givenExpression: `level IN "info"`,
+			keySet:          map[string]*config.Key{},
+			wantsError:      true,
+		},
+		{
+			name: `wants true - exists and missing`,
+			whenJsonRow: `
+					{
+						"http": {
+							"status": 200
+						},
+						"trace": null
+					}`,
+			givenExpression: `EXISTS http/status AND MISSING trace AND missing user/id`,
+			keySet:          map[string]*config.Key{},
+			wantsResult:     true,
+		},
+		{
+			name: `wants false - exists`,
+			whenJsonRow: `
+					{
+						"http": "none"
+					}`,
+			givenExpression: `exists http/status`,
+			keySet:          map[string]*config.Key{},
+			wantsResult:     false,
+		},
 	}
 	for _, test := range tests {
 		t.Run(test.name, func(t *testing.T) {
@@ -333,3 +458,256 @@ func TestParseFilterExpression(t *testing.T) {
 		})
 	}
 }
+
+func TestParseFilterExpression_RelativeTime(t *testing.T) {
+	now := time.Date(2022, 7, 20, 11, 30, 0, 0, time.Local)
+	defer func(n func() time.Time) { timeNow = n }(timeNow)
+	timeNow = func() time.Time { return now }
+
+	keySet := map[string]*config.Key{
+		"ts": {
+			Name:   "ts",
+			Type:   config.TypeDateTime,
+			Layout: time.RFC3339,
+		},
+		"level": {
+			Name: "level",
+			Type: config.TypeString,
+		},
+	}
+	at := func(d time.Duration) map[string]any {
+		return map[string]any{"ts": now.Add(d).Format(time.RFC3339), "level": "ERROR"}
+	}
+	tests := []struct {
+		name            string
+		whenRow         map[string]any
+		givenExpression string
+		keySet          map[string]*config.Key
+		wantsResult     bool
+		wantsError      bool
+	}{
+		{
+			name:            `wants true - within now-15m`,
+			whenRow:         at(-10 * time.Minute),
+			givenExpression: `ts > now-15m`,
+			wantsResult:     true,
+		},
+		{
+			name:            `wants false - before now-15m`,
+			whenRow:         at(-20 * time.Minute),
+			givenExpression: `ts > now - 15m and level = "error"`,
+			wantsResult:     false,
+		},
+		{
+			name:            `wants true - quoted relative time`,
+			whenRow:         at(-2 * time.Hour),
+			givenExpression: `ts < 'now-1h'`,
+			wantsResult:     true,
+		},
+		{
+			name:            `wants true - between clock times today`,
+			whenRow:         at(-87 * time.Minute),
+			givenExpression: `ts BETWEEN '10:00' AND '10:05'`,
+			wantsResult:     true,
+		},
+		{
+			name:            `wants false - outside clock times today`,
+			whenRow:         at(-80 * time.Minute),
+			givenExpression: `ts between '10:00' and '10:05'`,
+			wantsResult:     false,
+		},
+		{
+			name:            `wants true - last 5m on template datetime key`,
+			whenRow:         at(-4 * time.Minute),
+			givenExpression: `LAST 5m AND level = "error"`,
+			wantsResult:     true,
+		},
+		{
+			name:            `wants false - last 5m`,
+			whenRow:         at(-6 * time.Minute),
+			givenExpression: `last 5m`,
+			wantsResult:     false,
+		},
+		{
+			name:            `wants true - key last 1h30m`,
+			whenRow:         at(-80 * time.Minute),
+			givenExpression: `ts LAST 1h30m`,
+			wantsResult:     true,
+		},
+		{
+			name:            `wants false - missing time doesn't match`,
+			whenRow:         map[string]any{"level": "ERROR"},
+			givenExpression: `ts > now-15m OR LAST 5m`,
+			wantsResult:     false,
+		},
+		{
+			name:            `wants error - last without datetime key`,
+			whenRow:         at(0),
+			givenExpression: `LAST 5m`,
+			keySet:          map[string]*config.Key{},
+			wantsError:      true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			exp, err := ParseFilterExpression(test.givenExpression)
+			assert.NoError(t, err)
+			ks := keySet
+			if test.keySet != nil {
+				ks = test.keySet
+			}
+			result, err := exp.Apply(test.whenRow, ks)
+			if test.wantsError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, test.wantsResult, result)
+			}
+		})
+	}
+}
+
+func TestExpression_ApplyConcurrently(t *testing.T) {
+	keySet := map[string]*config.Key{
+		"level": {Name: "level", Type: config.TypeString},
+		"n":     {Name: "n", Type: config.TypeNumber},
+	}
+	var wg sync.WaitGroup
+	for g := 0; g < 4; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 200; i++ {
+				exp, err := ParseFilterExpression(fmt.Sprintf(`level = "l%d" OR n > %d`, g, i))
+				assert.NoError(t, err)
+				got, err := exp.Apply(map[string]any{"level": fmt.Sprintf("l%d", g), "n": "0"}, keySet)
+				assert.NoError(t, err)
+				assert.True(t, got)
+			}
+		}(g)
+	}
+	wg.Wait()
+}
+
+func TestExpression_ApplyInListsSharingText(t *testing.T) {
+	row := map[string]any{"level": "a b"}
+	for _, test := range []struct {
+		givenExpression string
+		wantsResult     bool
+	}{
+		{givenExpression: `level IN ('a', 'b')`, wantsResult: false},
+		{givenExpression: `level IN ('a b')`, wantsResult: true},
+	} {
+		exp, err := ParseFilterExpression(test.givenExpression)
+		assert.NoError(t, err)
+		got, err := exp.Apply(row, map[string]*config.Key{})
+		assert.NoError(t, err)
+		assert.Equal(t, test.wantsResult, got, test.givenExpression)
+	}
+}
+
+func TestValue_ToString(t *testing.T) {
+	number := func(n float64) *Value { return &Value{Number: &n} }
+	tests := []struct {
+		name  string
+		given *Value
+		wants string
+	}{
+		{name: "integer", given: number(200), wants: "200"},
+		{name: "decimal", given: number(0.25), wants: "0.25"},
+		{name: "beyond six decimals", given: number(0.0000001), wants: "0.0000001"},
+		{name: "negative", given: number(-1.5), wants: "-1.5"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.wants, test.given.ToString())
+		})
+	}
+}
+
+func TestParseFilterExpression_NumberLiterals(t *testing.T) {
+	tests := []struct {
+		name            string
+		givenExpression string
+		keySet          map[string]*config.Key
+		wantsResult     bool
+	}{
+		{
+			// used to compare "200" to "200.000000" and never match
+			name:            `wants true - number on a key not typed as number`,
+			givenExpression: `status = 200`,
+			keySet:          map[string]*config.Key{},
+			wantsResult:     true,
+		},
+		{
+			name:            `wants true - number list on a key not typed as number`,
+			givenExpression: `status IN (500, 200)`,
+			keySet:          map[string]*config.Key{},
+			wantsResult:     true,
+		},
+		{
+			name:            `wants false - numbers aren't padded`,
+			givenExpression: `status = 200.5`,
+			keySet:          map[string]*config.Key{},
+			wantsResult:     false,
+		},
+		{
+			name:            `wants true - number on a number key, unchanged`,
+			givenExpression: `status = 200.0`,
+			keySet: map[string]*config.Key{
+				"status": {Name: "status", Type: config.TypeNumber},
+			},
+			wantsResult: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			exp, err := ParseFilterExpression(test.givenExpression)
+			assert.NoError(t, err)
+			result, err := exp.Apply(map[string]any{"status": "200"}, test.keySet)
+			assert.NoError(t, err)
+			assert.Equal(t, test.wantsResult, result)
+		})
+	}
+}
+
+func TestParseFilterExpression_KeywordPrefixedKeys(t *testing.T) {
+	now := time.Now()
+	row := map[string]any{
+		"in":        map[string]any{"x": "a"},
+		"not.level": "b",
+		"last":      map[string]any{"seen": "c"},
+		"now":       map[string]any{"at": "d"},
+		"index":     "e",
+		"ts":        now.Format(time.RFC3339),
+	}
+	keySet := map[string]*config.Key{
+		"ts": {Name: "ts", Type: config.TypeDateTime, Layout: time.RFC3339},
+	}
+	tests := []struct {
+		name            string
+		givenExpression string
+		wantsResult     bool
+		wantsError      bool
+	}{
+		{name: `key path starting with in`, givenExpression: `in/x = 'a'`, wantsResult: true},
+		{name: `key path starting with not`, givenExpression: `not.level = 'b' AND NOT not.level = 'x'`, wantsResult: true},
+		{name: `key path starting with last`, givenExpression: `EXISTS last/seen AND LAST 1h`, wantsResult: true},
+		{name: `key path starting with now`, givenExpression: `now/at IN ('d') AND ts > now-1h`, wantsResult: true},
+		{name: `key starting with a keyword`, givenExpression: `index = 'e'`, wantsResult: true},
+		{name: `bare key named after a keyword`, givenExpression: `in = 'a'`, wantsError: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			exp, err := ParseFilterExpression(test.givenExpression)
+			if test.wantsError {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			result, err := exp.Apply(row, keySet)
+			assert.NoError(t, err)
+			assert.Equal(t, test.wantsResult, result)
+		})
+	}
+}
@@ -0,0 +1,156 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package filter
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	parentPath  = ".loggo"
+	filtersPath = "filters"
+	filterExt   = ".yaml"
+)
+
+// Saved is a named filter expression.
+type Saved struct {
+	Name       string `yaml:"name"`
+	Expression string `yaml:"expression"`
+}
+
+// Library stores named filter expressions, one yaml file each.
+type Library struct {
+	dir string
+}
+
+// NewLibrary makes a library backed by the given directory.
+func NewLibrary(dir string) *Library {
+	return &Library{dir: dir}
+}
+
+// DefaultLibrary is the library under ~/.loggo/filters.
+func DefaultLibrary() (*Library, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil, err
+	}
+	return NewLibrary(filepath.Join(home, parentPath, filtersPath)), nil
+}
+
+// Save stores the expression under the given name, replacing any filter
+// previously saved with the same name.
+func (l *Library) Save(name, expression string) error {
+	name, fileName, err := l.fileName(name)
+	if err != nil {
+		return err
+	}
+	if _, err := ParseFilterExpression(expression); err != nil {
+		return fmt.Errorf("invalid filter expression: %w", err)
+	}
+	if err := os.MkdirAll(l.dir, os.ModePerm); err != nil {
+		return err
+	}
+	b, err := yaml.Marshal(&Saved{Name: name, Expression: expression})
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(fileName, b, 0644)
+}
+
+// Load reads the filter saved under the given name.
+func (l *Library) Load(name string) (*Saved, error) {
+	name, fileName, err := l.fileName(name)
+	if err != nil {
+		return nil, err
+	}
+	b, err := os.ReadFile(fileName)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("no saved filter named %s", name)
+		}
+		return nil, err
+	}
+	s := &Saved{}
+	if err := yaml.Unmarshal(b, s); err != nil {
+		return nil, err
+	}
+	s.Name = name
+	return s, nil
+}
+
+// Remove deletes the filter saved under the given name.
+func (l *Library) Remove(name string) error {
+	_, fileName, err := l.fileName(name)
+	if err != nil {
+		return err
+	}
+	return os.Remove(fileName)
+}
+
+// List returns the saved filters sorted by name.
+func (l *Library) List() ([]*Saved, error) {
+	files, err := os.ReadDir(l.dir)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	list := make([]*Saved, 0, len(files))
+	for _, file := range files {
+		if file.IsDir() || filepath.Ext(file.Name()) != filterExt {
+			continue
+		}
+		if s, err := l.Load(strings.TrimSuffix(file.Name(), filterExt)); err == nil {
+			list = append(list, s)
+		}
+	}
+	sort.SliceStable(list, func(i, j int) bool {
+		return list[i].Name < list[j].Name
+	})
+	return list, nil
+}
+
+// Resolve returns the expression saved under nameOrExpression or, if there's
+// no such filter, nameOrExpression itself as an inline expression.
+func (l *Library) Resolve(nameOrExpression string) string {
+	if s, err := l.Load(nameOrExpression); err == nil {
+		return s.Expression
+	}
+	return nameOrExpression
+}
+
+func (l *Library) fileName(name string) (string, string, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if len(name) == 0 || strings.ContainsAny(name, `/\`) || strings.HasPrefix(name, ".") {
+		return "", "", fmt.Errorf("invalid filter name %q", name)
+	}
+	return name, filepath.Join(l.dir, name+filterExt), nil
+}
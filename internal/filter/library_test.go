@@ -0,0 +1,64 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package filter
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLibrary(t *testing.T) {
+	lib := NewLibrary(filepath.Join(t.TempDir(), "filters"))
+
+	list, err := lib.List()
+	assert.NoError(t, err)
+	assert.Empty(t, list)
+
+	assert.NoError(t, lib.Save("No Probes", `NOT path IN ("/healthz", "/ready")`))
+	assert.NoError(t, lib.Save("errors", `level = "error"`))
+	assert.NoError(t, lib.Save("errors", `level IN ("error", "fatal")`))
+	assert.Error(t, lib.Save("broken", `level = `))
+	assert.Error(t, lib.Save("../escape", `level = "error"`))
+	assert.Error(t, lib.Save(" ", `level = "error"`))
+
+	list, err = lib.List()
+	assert.NoError(t, err)
+	assert.Equal(t, []*Saved{
+		{Name: "errors", Expression: `level IN ("error", "fatal")`},
+		{Name: "no probes", Expression: `NOT path IN ("/healthz", "/ready")`},
+	}, list)
+
+	s, err := lib.Load("NO PROBES")
+	assert.NoError(t, err)
+	assert.Equal(t, `NOT path IN ("/healthz", "/ready")`, s.Expression)
+
+	assert.Equal(t, `level IN ("error", "fatal")`, lib.Resolve("errors"))
+	assert.Equal(t, `level = "warn"`, lib.Resolve(`level = "warn"`))
+
+	assert.NoError(t, lib.Remove("errors"))
+	_, err = lib.Load("errors")
+	assert.Error(t, err)
+	assert.Error(t, lib.Remove("errors"))
+}
@@ -0,0 +1,69 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package filter
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+
+	"github.com/aurc/loggo/internal/config"
+	"github.com/aurc/loggo/internal/decoder"
+)
+
+const maxLineSize = 4 * 1024 * 1024
+
+// Stream reads lines from in, decodes each one with dec and writes to out the
+// original lines whose entry matches the expression. Lines the expression can't
+// be evaluated against (e.g. a non numeric value for a number key) are skipped,
+// unless strict is set, in which case the first one stops the stream. It returns
+// the number of matching and skipped lines.
+func (c *Expression) Stream(in io.Reader, out io.Writer, key map[string]*config.Key, dec decoder.Chain,
+	strict bool) (matched, skipped int, err error) {
+	scanner := bufio.NewScanner(in)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineSize)
+	w := bufio.NewWriter(out)
+	defer w.Flush()
+	for n := 1; scanner.Scan(); n++ {
+		line := scanner.Text()
+		if len(line) == 0 {
+			continue
+		}
+		ok, err := c.Apply(dec.Decode(line), key)
+		if err != nil {
+			if strict {
+				return matched, skipped, fmt.Errorf("line %d: %w", n, err)
+			}
+			skipped++
+			continue
+		}
+		if !ok {
+			continue
+		}
+		matched++
+		if _, err := w.WriteString(line + "\n"); err != nil {
+			return matched, skipped, err
+		}
+	}
+	return matched, skipped, scanner.Err()
+}
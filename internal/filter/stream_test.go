@@ -0,0 +1,110 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package filter
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/aurc/loggo/internal/config"
+	"github.com/aurc/loggo/internal/decoder"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExpression_Stream(t *testing.T) {
+	input := strings.Join([]string{
+		`{"severity":"ERROR","latency":"900"}`,
+		`{"severity":"INFO","latency":"1000"}`,
+		``,
+		`severity=ERROR latency=200`,
+		`severity=error latency=700`,
+		`plain text line`,
+		`severity=warn latency=slow`,
+	}, "\n")
+	keySet := map[string]*config.Key{
+		"latency": {
+			Name: "latency",
+			Type: config.TypeNumber,
+		},
+	}
+	tests := []struct {
+		name            string
+		givenExpression string
+		givenStrict     bool
+		wantsOutput     string
+		wantsMatched    int
+		wantsSkipped    int
+		wantsError      bool
+	}{
+		{
+			name:            "typed keys across json and logfmt",
+			givenExpression: `severity = 'ERROR' AND latency > 500`,
+			wantsOutput: `{"severity":"ERROR","latency":"900"}` + "\n" +
+				`severity=error latency=700` + "\n",
+			wantsMatched: 2,
+			wantsSkipped: 1,
+		},
+		{
+			name:            "global token on plain text",
+			givenExpression: `"plain"`,
+			wantsOutput:     "plain text line\n",
+			wantsMatched:    1,
+		},
+		{
+			name:            "lines that can't be evaluated are skipped",
+			givenExpression: `latency > 800`,
+			wantsOutput: `{"severity":"ERROR","latency":"900"}` + "\n" +
+				`{"severity":"INFO","latency":"1000"}` + "\n",
+			wantsMatched: 2,
+			wantsSkipped: 1,
+		},
+		{
+			name:            "strict stops at a line that can't be evaluated",
+			givenExpression: `latency > 800`,
+			givenStrict:     true,
+			wantsError:      true,
+		},
+		{
+			name:            "bad value for typed key",
+			givenExpression: `latency > 'abc'`,
+			wantsSkipped:    6,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			exp, err := ParseFilterExpression(test.givenExpression)
+			assert.NoError(t, err)
+			out := &bytes.Buffer{}
+			matched, skipped, err := exp.Stream(strings.NewReader(input), out, keySet, decoder.Default(), test.givenStrict)
+			if test.wantsError {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, test.wantsMatched, matched)
+			assert.Equal(t, test.wantsSkipped, skipped)
+			assert.Equal(t, test.wantsOutput, out.String())
+		})
+	}
+}
@@ -0,0 +1,141 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package filter
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aurc/loggo/internal/config"
+)
+
+// timeNow is the clock relative time expressions are evaluated against.
+var timeNow = time.Now
+
+var (
+	durationPartReg = regexp.MustCompile(`(\d+(?:\.\d+)?)(ms|s|m|h|d|w)`)
+	relativeTimeReg = regexp.MustCompile(`(?i)^now(?:\s*([-+])\s*(\S+))?$`)
+
+	// literalLayouts are tried, in local time, when a literal doesn't match
+	// the key layout. Clock only literals refer to today.
+	literalLayouts = []string{
+		time.DateTime,
+		time.DateOnly,
+		"2006-01-02T15:04:05",
+	}
+	clockLayouts = []string{
+		"15:04",
+		"15:04:05",
+		"15:04:05.000",
+	}
+)
+
+var durationUnits = map[string]time.Duration{
+	"ms": time.Millisecond,
+	"s":  time.Second,
+	"m":  time.Minute,
+	"h":  time.Hour,
+	"d":  24 * time.Hour,
+	"w":  7 * 24 * time.Hour,
+}
+
+// parseDuration parses durations like 15m, 1h30m or 2d. Unlike
+// time.ParseDuration, days (d) and weeks (w) are accepted.
+func parseDuration(s string) (time.Duration, error) {
+	s = strings.ToLower(strings.TrimSpace(s))
+	parts := durationPartReg.FindAllStringSubmatchIndex(s, -1)
+	if len(parts) == 0 {
+		return 0, fmt.Errorf("invalid duration %q", s)
+	}
+	var d time.Duration
+	end := 0
+	for _, p := range parts {
+		if p[0] != end {
+			return 0, fmt.Errorf("invalid duration %q", s)
+		}
+		n, err := strconv.ParseFloat(s[p[2]:p[3]], 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration %q", s)
+		}
+		d += time.Duration(n * float64(durationUnits[s[p[4]:p[5]]]))
+		end = p[1]
+	}
+	if end != len(s) {
+		return 0, fmt.Errorf("invalid duration %q", s)
+	}
+	return d, nil
+}
+
+func layoutOf(key *config.Key) string {
+	if key == nil || len(key.Layout) == 0 {
+		return time.RFC3339Nano
+	}
+	return key.Layout
+}
+
+// parseLogTime parses a log value of a datetime key. Layouts without a time
+// zone are read as local time.
+func parseLogTime(key *config.Key, value string) (time.Time, error) {
+	return time.ParseInLocation(layoutOf(key), value, time.Local)
+}
+
+// parseTimeExpression parses the time a filter compares against. Besides
+// literals in the key layout it accepts now-relative expressions (now,
+// now-15m, now+1h), plain dates/date times and clock times (10:00, 10:00:30)
+// meaning today, all in local time.
+func parseTimeExpression(key *config.Key, expr string) (time.Time, error) {
+	expr = strings.TrimSpace(expr)
+	if m := relativeTimeReg.FindStringSubmatch(expr); m != nil {
+		now := timeNow()
+		if len(m[1]) == 0 {
+			return now, nil
+		}
+		d, err := parseDuration(m[2])
+		if err != nil {
+			return time.Time{}, err
+		}
+		if m[1] == "-" {
+			d = -d
+		}
+		return now.Add(d), nil
+	}
+	t, err := parseLogTime(key, expr)
+	if err == nil {
+		return t, nil
+	}
+	for _, l := range literalLayouts {
+		if lt, lErr := time.ParseInLocation(l, expr, time.Local); lErr == nil {
+			return lt, nil
+		}
+	}
+	for _, l := range clockLayouts {
+		if ct, cErr := time.ParseInLocation(l, expr, time.Local); cErr == nil {
+			y, mo, d := timeNow().In(time.Local).Date()
+			return time.Date(y, mo, d, ct.Hour(), ct.Minute(), ct.Second(), ct.Nanosecond(), time.Local), nil
+		}
+	}
+	return time.Time{}, err
+}
@@ -0,0 +1,95 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package filter
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aurc/loggo/internal/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseDuration(t *testing.T) {
+	tests := []struct {
+		given      string
+		wants      time.Duration
+		wantsError bool
+	}{
+		{given: "15m", wants: 15 * time.Minute},
+		{given: "1h30m", wants: 90 * time.Minute},
+		{given: "2d", wants: 48 * time.Hour},
+		{given: "1w", wants: 7 * 24 * time.Hour},
+		{given: "500ms", wants: 500 * time.Millisecond},
+		{given: "1.5h", wants: 90 * time.Minute},
+		{given: "15", wantsError: true},
+		{given: "15x", wantsError: true},
+		{given: "m15m", wantsError: true},
+	}
+	for _, test := range tests {
+		t.Run(test.given, func(t *testing.T) {
+			d, err := parseDuration(test.given)
+			if test.wantsError {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, test.wants, d)
+		})
+	}
+}
+
+func TestParseTimeExpression(t *testing.T) {
+	now := time.Date(2022, 7, 20, 11, 30, 0, 0, time.Local)
+	defer func(n func() time.Time) { timeNow = n }(timeNow)
+	timeNow = func() time.Time { return now }
+
+	key := &config.Key{Name: "ts", Type: config.TypeDateTime, Layout: time.RFC3339}
+	tests := []struct {
+		given      string
+		wants      time.Time
+		wantsError bool
+	}{
+		{given: "now", wants: now},
+		{given: "NOW-15m", wants: now.Add(-15 * time.Minute)},
+		{given: "now + 1h", wants: now.Add(time.Hour)},
+		{given: "10:05", wants: time.Date(2022, 7, 20, 10, 5, 0, 0, time.Local)},
+		{given: "10:05:30", wants: time.Date(2022, 7, 20, 10, 5, 30, 0, time.Local)},
+		{given: "2022-07-19", wants: time.Date(2022, 7, 19, 0, 0, 0, 0, time.Local)},
+		{given: "2022-07-19 08:00:00", wants: time.Date(2022, 7, 19, 8, 0, 0, 0, time.Local)},
+		{given: "2022-07-19T08:00:00Z", wants: time.Date(2022, 7, 19, 8, 0, 0, 0, time.UTC)},
+		{given: "now-15", wantsError: true},
+		{given: "yesterday", wantsError: true},
+	}
+	for _, test := range tests {
+		t.Run(test.given, func(t *testing.T) {
+			v, err := parseTimeExpression(key, test.given)
+			if test.wantsError {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.True(t, test.wants.Equal(v), "wants %v, got %v", test.wants, v)
+		})
+	}
+}
@@ -23,7 +23,11 @@ THE SOFTWARE.
 package loggo
 
 import (
+	"time"
+
+	"github.com/aurc/loggo/internal/buffer"
 	"github.com/aurc/loggo/internal/config"
+	"github.com/aurc/loggo/internal/filter"
 	"github.com/aurc/loggo/internal/reader"
 	"github.com/aurc/loggo/internal/util"
 	"github.com/gdamore/tcell/v2"
@@ -34,8 +38,77 @@ var BuildVersion string
 
 type LoggoApp struct {
 	appScaffold
-	chanReader reader.Reader
-	logView    *LogView
+	chanReader      reader.Reader
+	logView         *LogView
+	split           *LogView
+	active          *LogView
+	layout          *tview.Flex
+	splitShown      bool
+	splitHorizontal bool
+	syncScroll      bool
+	syncTimer       *time.Timer
+	history         *filter.History
+	options         options
+}
+
+type options struct {
+	retention buffer.Options
+	filter    string
+	context   int
+	split     *splitOptions
+	sync      bool
+}
+
+type splitOptions struct {
+	reader     reader.Reader
+	filter     string
+	horizontal bool
+}
+
+// Option customises how the LoggoApp buffers and presents the stream.
+type Option func(o *options)
+
+// WithRetention bounds how many streamed entries are kept, see buffer.Options.
+func WithRetention(retention buffer.Options) Option {
+	return func(o *options) {
+		o.retention = retention
+	}
+}
+
+// WithFilter starts the stream with the given local filter expression applied.
+func WithFilter(expression string) Option {
+	return func(o *options) {
+		o.filter = expression
+	}
+}
+
+// WithContext includes the given number of entries before and after each
+// entry matching the local filter, like grep -C.
+func WithContext(lines int) Option {
+	return func(o *options) {
+		o.context = lines
+	}
+}
+
+// WithSplit starts with a second pane below (horizontal) or beside the main
+// one, showing the entries of reader or, if nil, of the main input. The
+// expression, if not empty, is the local filter of the second pane.
+func WithSplit(reader reader.Reader, expression string, horizontal bool) Option {
+	return func(o *options) {
+		o.split = &splitOptions{
+			reader:     reader,
+			filter:     expression,
+			horizontal: horizontal,
+		}
+	}
+}
+
+// WithSyncScroll starts with the scrolling of the split panes synchronised by
+// the time of their entries.
+func WithSyncScroll() Option {
+	return func(o *options) {
+		o.sync = true
+	}
 }
 
 type Loggo interface {
@@ -52,22 +125,38 @@ type Loggo interface {
 	PopView()
 }
 
-func NewLoggoApp(reader reader.Reader, configFile string) *LoggoApp {
+func NewLoggoApp(reader reader.Reader, configFile string, opts ...Option) *LoggoApp {
 	app := NewApp(configFile)
 	lapp := &LoggoApp{
 		appScaffold: *app,
 		chanReader:  reader,
 	}
+	for _, o := range opts {
+		o(&lapp.options)
+	}
 
 	lapp.logView = NewLogReader(lapp, reader)
+	lapp.active = lapp.logView
+	lapp.syncScroll = lapp.options.sync
+	lapp.layout = tview.NewFlex()
+	lapp.keyEvents()
+	if s := lapp.options.split; s != nil {
+		lapp.toggleSplit(s.horizontal)
+	} else {
+		lapp.makeLayouts()
+	}
 
 	lapp.pages = tview.NewPages().
-		AddPage("background", lapp.logView, true, true)
+		AddPage("background", lapp.layout, true, true)
 
 	return lapp
 }
 
 func (a *LoggoApp) Run() {
+	defer a.logView.inSlice.Close()
+	if a.split != nil && a.split.inSlice != a.logView.inSlice {
+		defer a.split.inSlice.Close()
+	}
 	if err := a.app.
 		SetRoot(a.pages, true).
 		EnableMouse(true).
@@ -76,3 +165,11 @@ func (a *LoggoApp) Run() {
 		panic(err)
 	}
 }
+
+// filterHistory is the history of filter expressions, shared by the panes.
+func (a *LoggoApp) filterHistory() *filter.History {
+	if a.history == nil {
+		a.history, _ = filter.DefaultHistory()
+	}
+	return a.history
+}
@@ -75,6 +75,12 @@ func (a *appScaffold) Draw() {
 	a.app.Draw()
 }
 
+// QueueUpdateDraw runs f on the event loop and redraws the screen, for
+// goroutines to safely update the UI.
+func (a *appScaffold) QueueUpdateDraw(f func()) {
+	a.app.QueueUpdateDraw(f)
+}
+
 func (a *appScaffold) SetInputCapture(cap func(event *tcell.EventKey) *tcell.EventKey) {
 	a.app.SetInputCapture(cap)
 }
@@ -0,0 +1,318 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package loggo
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/aurc/loggo/internal/buffer"
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// splitKeyEvents handles the keys opening, closing and moving between the
+// split panes.
+func (a *LoggoApp) splitKeyEvents(event *tcell.EventKey) *tcell.EventKey {
+	if event.Key() == tcell.KeyCtrlP {
+		a.switchPane()
+		return nil
+	}
+	if !a.active.table.HasFocus() {
+		return event
+	}
+	switch event.Rune() {
+	case '|':
+		a.toggleSplit(false)
+		return nil
+	case '_':
+		a.toggleSplit(true)
+		return nil
+	case '=':
+		a.toggleSyncScroll()
+		return nil
+	}
+	return event
+}
+
+// toggleSplit opens a second pane beside (or below, if horizontal) the main
+// one, or closes it if already shown that way. The pane is kept while closed
+// so reopening it resumes where it was.
+func (a *LoggoApp) toggleSplit(horizontal bool) {
+	if a.splitShown && a.splitHorizontal == horizontal {
+		a.splitShown = false
+	} else {
+		if a.split == nil {
+			a.split = a.newSplitView()
+		}
+		a.splitShown = true
+		a.splitHorizontal = horizontal
+	}
+	a.makeLayouts()
+	if a.splitShown {
+		a.SetFocus(a.split.table)
+	} else {
+		a.SetFocus(a.logView.table)
+	}
+}
+
+// newSplitView builds the second pane: a view of its own reader if one was
+// given, or else another view of the main input to apply a different filter.
+func (a *LoggoApp) newSplitView() *LogView {
+	expression := ""
+	if s := a.options.split; s != nil {
+		expression = s.filter
+		if s.reader != nil {
+			lv, err := newLogView(a, s.reader, buffer.New(a.options.retention))
+			lv.start(expression)
+			lv.isFollowing = true
+			if err != nil {
+				lv.showDecoderError(err)
+			}
+			return lv
+		}
+	}
+	lv, _ := newLogView(a, nil, a.logView.inSlice)
+	lv.start(expression)
+	lv.isFollowing = true
+	return lv
+}
+
+func (a *LoggoApp) makeLayouts() {
+	a.layout.Clear()
+	a.logView.SetBorder(a.splitShown)
+	if !a.splitShown {
+		a.layout.AddItem(a.logView, 0, 1, true)
+		a.setActive(a.logView)
+		return
+	}
+	a.split.SetBorder(true)
+	if a.splitHorizontal {
+		a.layout.SetDirection(tview.FlexRow)
+	} else {
+		a.layout.SetDirection(tview.FlexColumn)
+	}
+	a.layout.AddItem(clipView{a.logView}, 0, 1, a.active == a.logView).
+		AddItem(clipView{a.split}, 0, 1, a.active == a.split)
+	a.updatePaneTitles()
+}
+
+// clipView keeps a pane from drawing outside of its area, as fixed width rows
+// like the filter bar's overflow narrow panes.
+type clipView struct {
+	*LogView
+}
+
+func (c clipView) Draw(screen tcell.Screen) {
+	x, y, width, height := c.GetRect()
+	c.LogView.Draw(clippedScreen{Screen: screen, x: x, y: y, width: width, height: height})
+}
+
+type clippedScreen struct {
+	tcell.Screen
+	x, y, width, height int
+}
+
+func (s clippedScreen) SetContent(x, y int, primary rune, combining []rune, style tcell.Style) {
+	if x >= s.x && x < s.x+s.width && y >= s.y && y < s.y+s.height {
+		s.Screen.SetContent(x, y, primary, combining, style)
+	}
+}
+
+// switchPane moves the focus to the other pane.
+func (a *LoggoApp) switchPane() {
+	if !a.splitShown {
+		return
+	}
+	if a.active == a.logView {
+		a.SetFocus(a.split.table)
+	} else {
+		a.SetFocus(a.logView.table)
+	}
+}
+
+// setActive makes the pane the one receiving the keys.
+func (a *LoggoApp) setActive(l *LogView) {
+	if a.active == l {
+		return
+	}
+	a.active = l
+	a.updatePaneTitles()
+}
+
+func (a *LoggoApp) updatePaneTitles() {
+	if !a.splitShown {
+		return
+	}
+	sync := ""
+	if a.syncScroll {
+		sync = " · synced"
+	}
+	for i, l := range []*LogView{a.logView, a.split} {
+		title := fmt.Sprintf(" %d%s ", i+1, sync)
+		if l == a.active {
+			l.SetBorderColor(tcell.ColorYellow).
+				SetTitleColor(tcell.ColorYellow).
+				SetTitle(title + "[^p] ")
+		} else {
+			l.SetBorderColor(tcell.ColorGray).
+				SetTitleColor(tcell.ColorGray).
+				SetTitle(title)
+		}
+	}
+}
+
+func (a *LoggoApp) toggleSyncScroll() {
+	a.syncScroll = !a.syncScroll
+	a.updatePaneTitles()
+	state := "[red::b]OFF"
+	if a.syncScroll {
+		state = "[green::b]ON"
+		a.syncPanes(a.active)
+	}
+	go a.ShowPopMessage(fmt.Sprintf("Synchronised scrolling %s[-::-]", state), 1, a.active.table)
+}
+
+// syncScrollDelay is how long the selection has to settle before the other
+// pane is scrolled to it, so holding an arrow key doesn't search for each row.
+const syncScrollDelay = 100 * time.Millisecond
+
+// syncPanes selects, in the other pane, the entry closest in time to the one
+// selected in l when scrolling is synchronised. When l follows the stream the
+// other pane does too.
+func (a *LoggoApp) syncPanes(l *LogView) {
+	if !a.syncScroll || !a.splitShown || l != a.active {
+		return
+	}
+	other := a.split
+	if l == a.split {
+		other = a.logView
+	}
+	if a.syncTimer != nil {
+		a.syncTimer.Stop()
+	}
+	if l.isFollowing {
+		other.isFollowing = true
+		return
+	}
+	r, _ := l.table.GetSelection()
+	a.syncTimer = time.AfterFunc(syncScrollDelay, func() {
+		at, ok := l.rowTime(r - 1)
+		if !ok {
+			return
+		}
+		if row, ok := other.closestRow(at); ok {
+			a.QueueUpdateDraw(func() {
+				other.isFollowing = false
+				other.table.Select(row+1, 0)
+				other.updateLineView()
+			})
+		}
+	})
+}
+
+// rowTime is the time of the entry at the given row index of the filtered
+// view: its datetime key if the template has one, or else when it was
+// streamed.
+func (l *LogView) rowTime(index int) (time.Time, bool) {
+	l.filterLock.RLock()
+	rows := l.rows()
+	if index < 0 || index >= len(rows) {
+		l.filterLock.RUnlock()
+		return time.Time{}, false
+	}
+	seq := rows[index]
+	l.filterLock.RUnlock()
+	return l.entryTime(seq)
+}
+
+func (l *LogView) entryTime(seq int64) (time.Time, bool) {
+	if k := l.timeKey(); k != nil {
+		if m, ok := l.inSlice.Get(seq); ok {
+			if t, ok := k.ExtractTime(m); ok {
+				return t, true
+			}
+		}
+	}
+	return l.inSlice.ArrivedAt(seq)
+}
+
+// closestRow returns the row index of the filtered view whose entry is the
+// closest in time to at. Rows in arrival order, or sorted by the template's
+// datetime key, are binary searched; any other order is scanned.
+func (l *LogView) closestRow(at time.Time) (int, bool) {
+	l.filterLock.RLock()
+	k := l.timeKey()
+	if len(l.sortKey) > 0 && (k == nil || l.sortKey != k.Name) {
+		rows := slices.Clone(l.rows())
+		l.filterLock.RUnlock()
+		found := -1
+		var best time.Duration
+		for i, seq := range rows {
+			if t, ok := l.entryTime(seq); ok {
+				if d := t.Sub(at).Abs(); found < 0 || d < best {
+					found, best = i, d
+				}
+			}
+		}
+		return found, found >= 0
+	}
+	defer l.filterLock.RUnlock()
+	rows := l.rows()
+	// timeFrom is the index and time of the first timed row from i on
+	timeFrom := func(i int) (int, time.Time, bool) {
+		for ; i < len(rows); i++ {
+			if t, ok := l.entryTime(rows[i]); ok {
+				return i, t, true
+			}
+		}
+		return len(rows), time.Time{}, false
+	}
+	desc := len(l.sortKey) > 0 && l.sortDesc
+	after := sort.Search(len(rows), func(i int) bool {
+		_, t, ok := timeFrom(i)
+		if !ok {
+			return true
+		}
+		if desc {
+			return !t.After(at)
+		}
+		return !t.Before(at)
+	})
+	found := -1
+	var best time.Duration
+	if i, t, ok := timeFrom(after); ok {
+		found, best = i, t.Sub(at).Abs()
+	}
+	for i := after - 1; i >= 0; i-- {
+		if t, ok := l.entryTime(rows[i]); ok {
+			if d := t.Sub(at).Abs(); found < 0 || d < best {
+				found = i
+			}
+			break
+		}
+	}
+	return found, found >= 0
+}
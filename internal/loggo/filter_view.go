@@ -24,6 +24,7 @@ package loggo
 
 import (
 	"fmt"
+	"strconv"
 	"strings"
 
 	"github.com/aurc/loggo/internal/char"
@@ -41,8 +42,22 @@ type FilterView struct {
 	expressionField *tview.InputField
 	buttonSearch    *tview.Button
 	buttonClear     *tview.Button
+	buttonSave      *tview.Button
+	buttonLoad      *tview.Button
+	buttonHighlight *tview.Button
+	contextField    *tview.InputField
 	keyFinderField  *tview.InputField
 	filterCallback  func(*filter.Expression)
+	applied         string
+	savedName       string
+	highlight       bool
+	history         *filter.History
+	historyPos      int
+	historyDraft    string
+	completing      bool
+	completion      filter.Completion
+	keySuggester    func() []string
+	valueSuggester  func(key string) []string
 }
 
 func NewFilterView(app Loggo, filterCallback func(*filter.Expression)) *FilterView {
@@ -50,6 +65,7 @@ func NewFilterView(app Loggo, filterCallback func(*filter.Expression)) *FilterVi
 		Flex:           *tview.NewFlex(),
 		app:            app,
 		filterCallback: filterCallback,
+		historyPos:     -1,
 	}
 	tv.makeUIComponents()
 	tv.makeLayouts()
@@ -63,16 +79,46 @@ func (t *FilterView) makeUIComponents() {
 		SetPlaceholderStyle(color.PlaceholderStyle)
 	t.expressionField.
 		SetBackgroundColor(color.ColorBackgroundField)
+	t.expressionField.
+		SetAutocompleteUseTags(false).
+		SetAutocompleteFunc(func(currentText string) []string {
+			if !t.completing {
+				return nil
+			}
+			entries := t.suggestions(currentText)
+			if len(entries) == 0 {
+				t.completing = false
+			}
+			return entries
+		}).
+		SetAutocompletedFunc(func(text string, index int, source int) bool {
+			if source == tview.AutocompletedNavigate {
+				return false
+			}
+			t.applySuggestion(text)
+			return true
+		})
 	t.buttonSearch = tview.NewButton("Search").SetSelectedFunc(func() {
 		t.search()
 	})
 	t.buttonClear = tview.NewButton("Clear").SetSelectedFunc(func() {
-		t.expressionField.SetText("")
+		t.Clear()
 		t.app.SetFocus(t.expressionField)
-		if t.filterCallback != nil {
-			t.filterCallback(nil)
-		}
 	})
+	t.buttonSave = tview.NewButton("Save").SetSelectedFunc(t.showSaveFilter)
+	t.buttonLoad = tview.NewButton("Load").SetSelectedFunc(t.showSavedFilters)
+	t.buttonHighlight = tview.NewButton("Highlight").SetSelectedFunc(t.toggleHighlight)
+	t.updateHighlightButton()
+	t.contextField = tview.NewInputField().
+		SetLabel("Context ").
+		SetText("0").
+		SetFieldWidth(3).
+		SetAcceptanceFunc(tview.InputFieldInteger).
+		SetDoneFunc(func(key tcell.Key) {
+			if key == tcell.KeyEnter && len(strings.TrimSpace(t.expressionField.GetText())) > 0 {
+				t.search()
+			}
+		})
 
 	t.keyFinderField = tview.NewInputField().SetPlaceholder("Start typing to find a key...")
 	t.keyFinderField.SetAutocompleteFunc(func(currentText string) (entries []string) {
@@ -106,16 +152,40 @@ func (t *FilterView) makeUIComponents() {
 	})
 
 	t.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if !t.expressionField.HasFocus() {
+			return event
+		}
+		if t.completing {
+			// the suggestions drop-down handles the keys
+			if event.Key() == tcell.KeyEsc {
+				t.completing = false
+			}
+			return event
+		}
 		switch event.Key() {
 		case tcell.KeyEnter:
-			if t.expressionField.HasFocus() {
-				t.search()
-				return nil
-			}
+			t.search()
+			return nil
 		case tcell.KeyEsc:
-			if t.expressionField.HasFocus() {
-				t.app.SetFocus(t.buttonClear)
-			}
+			t.app.SetFocus(t.buttonClear)
+		case tcell.KeyTab:
+			t.complete()
+			return nil
+		case tcell.KeyUp:
+			t.browseHistory(-1)
+			return nil
+		case tcell.KeyDown:
+			t.browseHistory(1)
+			return nil
+		case tcell.KeyCtrlS:
+			t.showSaveFilter()
+			return nil
+		case tcell.KeyCtrlO:
+			t.showSavedFilters()
+			return nil
+		case tcell.KeyCtrlG:
+			t.toggleHighlight()
+			return nil
 		}
 		return event
 	})
@@ -143,10 +213,79 @@ func (t *FilterView) search() {
 			}))
 		return
 	}
+	t.historyPos = -1
+	t.applied = t.expressionField.GetText()
+	if t.history != nil {
+		_ = t.history.Add(t.applied)
+	}
 	if t.filterCallback != nil {
 		t.filterCallback(exp)
 	}
 }
+
+// Highlight tells whether rows matching the filter are highlighted, instead of
+// the non-matching ones being hidden.
+func (t *FilterView) Highlight() bool {
+	return t.highlight
+}
+
+func (t *FilterView) toggleHighlight() {
+	t.highlight = !t.highlight
+	t.updateHighlightButton()
+	if len(strings.TrimSpace(t.expressionField.GetText())) > 0 {
+		t.search()
+	}
+	t.app.SetFocus(t.expressionField)
+}
+
+func (t *FilterView) updateHighlightButton() {
+	if t.highlight {
+		t.buttonHighlight.SetStyle(tcell.StyleDefault.Background(tcell.ColorGreen).Foreground(tcell.ColorWhite))
+	} else {
+		t.buttonHighlight.SetStyle(tcell.StyleDefault.Background(tcell.ColorGray).Foreground(tcell.ColorWhite))
+	}
+}
+
+// Context is the number of entries to show before and after each match.
+func (t *FilterView) Context() int {
+	n, err := strconv.Atoi(t.contextField.GetText())
+	if err != nil || n < 0 {
+		return 0
+	}
+	return n
+}
+
+// SetHistory sets the history the applied expressions are added to and
+// recalled from.
+func (t *FilterView) SetHistory(history *filter.History) {
+	t.history = history
+}
+
+// SetContext sets the number of entries to show before and after each match.
+func (t *FilterView) SetContext(lines int) {
+	t.contextField.SetText(strconv.Itoa(lines))
+}
+
+// Clear removes the filter expression, showing every entry.
+func (t *FilterView) Clear() {
+	t.expressionField.SetText("")
+	t.applied = ""
+	if t.filterCallback != nil {
+		t.filterCallback(nil)
+	}
+}
+
+// Applied is the filter expression currently applied, empty if none.
+func (t *FilterView) Applied() string {
+	return strings.TrimSpace(t.applied)
+}
+
+// SetExpression fills in the filter expression and applies it.
+func (t *FilterView) SetExpression(expression string) {
+	t.expressionField.SetText(expression)
+	t.search()
+}
+
 func (t *FilterView) addKey() {
 	tex := t.expressionField.GetText()
 	t.expressionField.SetText(tex + " " + t.keyFinderField.GetText())
@@ -167,11 +306,17 @@ func (t *FilterView) makeLayouts() {
 			AddItem(tview.NewBox(), 1, 1, false).
 			AddItem(tview.NewFlex().SetDirection(tview.FlexColumn).
 				AddItem(tview.NewBox(), 1, 1, false).
-				AddItem(t.buttonSearch, 10, 1, false).
+				AddItem(t.buttonSearch, 8, 1, false).
+				AddItem(tview.NewBox(), 1, 1, false).
+				AddItem(t.buttonClear, 7, 1, false).
+				AddItem(tview.NewBox(), 1, 1, false).
+				AddItem(t.buttonSave, 6, 1, false).
 				AddItem(tview.NewBox(), 1, 1, false).
-				AddItem(t.buttonClear, 10, 1, false), 1, 1, false).
+				AddItem(t.buttonLoad, 6, 1, false).
+				AddItem(tview.NewBox(), 1, 1, false).
+				AddItem(t.buttonHighlight, 11, 1, false), 1, 1, false).
 			AddItem(tview.NewBox(), 1, 1, false),
-			23, 1, true)
+			44, 1, true)
 
 	okButton := tview.NewButton("OK").SetSelectedFunc(t.addKey)
 	okButton.SetBackgroundColor(tcell.ColorGreen)
@@ -192,10 +337,16 @@ func (t *FilterView) makeLayouts() {
 	t.addButton(actionBar, "CONTAINS")
 	t.addButton(actionBar, "BETWEEN")
 	t.addButton(actionBar, "MATCH")
+	t.addButton(actionBar, "IN")
+	t.addButton(actionBar, "LAST")
 	actionBar.AddItem(tview.NewTextView().SetText(" |"), 2, 0, false)
 	t.addButton(actionBar, "AND")
 	t.addButton(actionBar, "OR")
-	actionBar.AddItem(tview.NewBox(), 24, 1, false)
+	t.addButton(actionBar, "NOT")
+	actionBar.AddItem(tview.NewTextView().SetText(" |"), 2, 0, false).
+		AddItem(tview.NewBox(), 1, 1, false).
+		AddItem(t.contextField, 11, 1, false).
+		AddItem(tview.NewBox(), 6, 1, false)
 
 	t.Flex.Clear().SetDirection(tview.FlexRow).
 		AddItem(filterRow, 3, 1, false).
@@ -0,0 +1,143 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package loggo
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/aurc/loggo/internal/config"
+	"github.com/aurc/loggo/internal/filter"
+)
+
+// SetKeySuggester sets the source of the keys suggested, besides the template
+// ones, when completing a key.
+func (t *FilterView) SetKeySuggester(suggester func() []string) {
+	t.keySuggester = suggester
+}
+
+// SetValueSuggester sets the source of the values suggested, most frequent
+// first, when completing the value of a key.
+func (t *FilterView) SetValueSuggester(suggester func(key string) []string) {
+	t.valueSuggester = suggester
+}
+
+// complete suggests the key or value being typed. A single suggestion is
+// applied straight away, otherwise a drop-down is presented.
+func (t *FilterView) complete() {
+	t.completing = true
+	entries := t.suggestions(t.expressionField.GetText())
+	switch len(entries) {
+	case 0:
+		t.completing = false
+	case 1:
+		t.applySuggestion(entries[0])
+	default:
+		t.expressionField.Autocomplete()
+	}
+}
+
+func (t *FilterView) suggestions(text string) []string {
+	t.completion = filter.Complete(text)
+	var candidates []string
+	if t.completion.IsValue() {
+		if t.valueSuggester == nil {
+			return nil
+		}
+		isNumber := t.keyType(t.completion.Key) == config.TypeNumber
+		for _, v := range t.valueSuggester(t.completion.Key) {
+			if _, err := strconv.ParseFloat(v, 64); isNumber && err == nil {
+				candidates = append(candidates, v)
+			} else {
+				candidates = append(candidates, filter.QuoteValue(v))
+			}
+		}
+	} else {
+		if strings.ContainsAny(t.completion.Partial, `'"`) {
+			return nil
+		}
+		seen := make(map[string]bool)
+		for _, k := range t.app.Config().Keys {
+			seen[k.Name] = true
+			candidates = append(candidates, k.Name)
+		}
+		if t.keySuggester != nil {
+			for _, k := range t.keySuggester() {
+				if !seen[k] {
+					seen[k] = true
+					candidates = append(candidates, k)
+				}
+			}
+		}
+	}
+	partial := strings.ToLower(strings.Trim(t.completion.Partial, `'"`))
+	matches := make([]string, 0, len(candidates))
+	for _, c := range candidates {
+		if strings.Contains(strings.ToLower(strings.Trim(c, `'"`)), partial) {
+			matches = append(matches, c)
+		}
+	}
+	return matches
+}
+
+func (t *FilterView) applySuggestion(suggestion string) {
+	t.completing = false
+	t.expressionField.SetText(t.completion.Prefix + suggestion + " ")
+}
+
+func (t *FilterView) keyType(name string) config.Type {
+	for _, k := range t.app.Config().Keys {
+		if k.Name == name {
+			return k.Type
+		}
+	}
+	return config.TypeString
+}
+
+// browseHistory replaces the expression with an older (step < 0) or newer
+// (step > 0) one from the history. Moving past the newest entry restores
+// what was being typed.
+func (t *FilterView) browseHistory(step int) {
+	if t.history == nil || len(t.history.Entries()) == 0 {
+		return
+	}
+	entries := t.history.Entries()
+	if t.historyPos < 0 {
+		if step > 0 {
+			return
+		}
+		t.historyDraft = t.expressionField.GetText()
+		t.historyPos = len(entries)
+	}
+	pos := t.historyPos + step
+	if pos >= len(entries) {
+		t.historyPos = -1
+		t.expressionField.SetText(t.historyDraft)
+		return
+	}
+	if pos < 0 {
+		pos = 0
+	}
+	t.historyPos = pos
+	t.expressionField.SetText(entries[pos])
+}
@@ -0,0 +1,158 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package loggo
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/aurc/loggo/internal/filter"
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+func (t *FilterView) library() (*filter.Library, bool) {
+	lib, err := filter.DefaultLibrary()
+	if err != nil {
+		t.app.ShowPopMessage(fmt.Sprintf("[red::b]Saved filters unavailable:[-::-] %v", err), 4, t.expressionField)
+		return nil, false
+	}
+	return lib, true
+}
+
+func (t *FilterView) showSaveFilter() {
+	expression := strings.TrimSpace(t.expressionField.GetText())
+	if len(expression) == 0 {
+		t.app.ShowPopMessage("Type a filter expression to save first", 2, t.expressionField)
+		return
+	}
+	lib, ok := t.library()
+	if !ok {
+		return
+	}
+	nameField := tview.NewInputField().
+		SetLabel("Name").
+		SetText(t.savedName)
+	save := func() {
+		name := nameField.GetText()
+		if err := lib.Save(name, expression); err != nil {
+			t.app.DismissModal(t.expressionField)
+			t.app.ShowPopMessage(fmt.Sprintf("[red::b]Unable to save filter:[-::-] %v", err), 4, t.expressionField)
+			return
+		}
+		t.savedName = strings.ToLower(strings.TrimSpace(name))
+		t.app.DismissModal(t.expressionField)
+		t.app.ShowPopMessage(fmt.Sprintf("Filter saved as [green::b]%s[-::-]", t.savedName), 2, t.expressionField)
+	}
+	form := tview.NewForm().
+		AddFormItem(nameField).
+		AddButton("Save", save).
+		AddButton("Cancel", func() {
+			t.app.DismissModal(t.expressionField)
+		})
+	form.SetBorderPadding(1, 0, 1, 1).
+		SetBackgroundColor(tcell.ColorDarkBlue)
+	t.app.ShowModal(form, 60, 7, tcell.ColorDarkBlue, func(event *tcell.EventKey) *tcell.EventKey {
+		switch event.Key() {
+		case tcell.KeyEsc:
+			t.app.DismissModal(t.expressionField)
+			return nil
+		case tcell.KeyEnter:
+			if nameField.HasFocus() {
+				save()
+				return nil
+			}
+		}
+		return event
+	})
+	t.app.SetFocus(nameField)
+}
+
+func (t *FilterView) showSavedFilters() {
+	lib, ok := t.library()
+	if !ok {
+		return
+	}
+	saved, err := lib.List()
+	if err != nil {
+		t.app.ShowPopMessage(fmt.Sprintf("[red::b]Unable to list saved filters:[-::-] %v", err), 4, t.expressionField)
+		return
+	}
+	if len(saved) == 0 {
+		t.app.ShowPopMessage("No saved filters yet, use Save to add one", 2, t.expressionField)
+		return
+	}
+	list := tview.NewList().
+		SetSecondaryTextColor(tcell.ColorLightGrey).
+		SetSelectedFocusOnly(false)
+	list.SetBackgroundColor(tcell.ColorDarkBlue)
+	for _, s := range saved {
+		list.AddItem(s.Name, s.Expression, 0, func() {
+			t.app.DismissModal(t.expressionField)
+			t.savedName = s.Name
+			t.SetExpression(s.Expression)
+		})
+	}
+	help := tview.NewTextView().
+		SetDynamicColors(true).
+		SetText(`[yellow::b]Enter[-::-] Load  [yellow::b]d[-::-] Delete  [yellow::b]Esc[-::-] Close`)
+	help.SetBackgroundColor(tcell.ColorDarkBlue)
+	content := tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(list, 0, 1, true).
+		AddItem(help, 1, 1, false)
+	content.SetBorderPadding(0, 0, 1, 1).
+		SetBackgroundColor(tcell.ColorDarkBlue)
+
+	height := 2*len(saved) + 3
+	if height > 20 {
+		height = 20
+	}
+	t.app.ShowModal(content, 70, height, tcell.ColorDarkBlue, func(event *tcell.EventKey) *tcell.EventKey {
+		switch event.Key() {
+		case tcell.KeyEsc:
+			t.app.DismissModal(t.expressionField)
+			return nil
+		case tcell.KeyDelete:
+		default:
+			if event.Rune() != 'd' {
+				return event
+			}
+		}
+		i := list.GetCurrentItem()
+		if i < 0 || i >= len(saved) {
+			return nil
+		}
+		if err := lib.Remove(saved[i].Name); err != nil {
+			t.app.DismissModal(t.expressionField)
+			t.app.ShowPopMessage(fmt.Sprintf("[red::b]Unable to delete filter:[-::-] %v", err), 4, t.expressionField)
+			return nil
+		}
+		saved = append(saved[:i], saved[i+1:]...)
+		list.RemoveItem(i)
+		if len(saved) == 0 {
+			t.app.DismissModal(t.expressionField)
+		}
+		return nil
+	})
+	t.app.SetFocus(list)
+}
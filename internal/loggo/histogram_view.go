@@ -0,0 +1,307 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package loggo
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"time"
+
+	"github.com/aurc/loggo/internal/aggregate"
+	"github.com/aurc/loggo/internal/config"
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+const histogramHeight = 6
+
+// histogramRefresh is how often, at most, the buckets are recomputed while
+// the filtered entries keep changing.
+const histogramRefresh = 500 * time.Millisecond
+
+// histogramPoint caches where an entry falls in the histogram.
+type histogramPoint struct {
+	at      time.Time
+	arrived time.Time
+	series  int
+}
+
+// HistogramView draws the volume of the filtered entries over time, one bar
+// per column, stacked by the colours of the severity key (the first template
+// key with color-when rules). Entries are placed by the first datetime key of
+// the template, or by the time they were streamed when there's none.
+type HistogramView struct {
+	*tview.Box
+	logView  *LogView
+	selected int
+	buckets  []aggregate.Bucket
+	colors   []tcell.Color
+	points   map[int64]histogramPoint
+	cacheKey string
+	// what the buckets were computed from
+	rowsGen   uint64
+	width     int
+	updatedAt time.Time
+	redraw    *time.Timer
+}
+
+func NewHistogramView(logView *LogView) *HistogramView {
+	h := &HistogramView{
+		Box:      tview.NewBox(),
+		logView:  logView,
+		selected: -1,
+		points:   make(map[int64]histogramPoint),
+	}
+	h.SetBackgroundColor(tcell.ColorBlack)
+	return h
+}
+
+func (h *HistogramView) Draw(screen tcell.Screen) {
+	h.Box.DrawForSubclass(screen, h)
+	x, y, width, height := h.GetInnerRect()
+	if width <= 0 || height <= 1 {
+		return
+	}
+	h.update(width)
+	if h.selected >= len(h.buckets) {
+		h.selected = len(h.buckets) - 1
+	}
+	bars := height - 1
+	peak := 0
+	for _, b := range h.buckets {
+		peak = max(peak, b.Total)
+	}
+	for i, b := range h.buckets {
+		if b.Total == 0 {
+			continue
+		}
+		cells := max(1, (b.Total*bars+peak-1)/peak)
+		style := tcell.StyleDefault.Background(tcell.ColorBlack)
+		if i == h.selected {
+			style = style.Background(tcell.ColorDarkSlateGray)
+		}
+		// stack the series from the bottom, each taking its share of the bar
+		cum, cell := 0, 0
+		for s, n := range b.Counts {
+			cum += n
+			top := (cum*cells + b.Total/2) / b.Total
+			for ; cell < top; cell++ {
+				screen.SetContent(x+i, y+bars-1-cell, '█', nil, style.Foreground(h.colors[s]))
+			}
+		}
+		if i == h.selected {
+			for c := cell; c < bars; c++ {
+				screen.SetContent(x+i, y+bars-1-c, ' ', nil, style)
+			}
+		}
+	}
+	tview.Print(screen, h.label(), x, y+bars, width, tview.AlignLeft, tcell.ColorLightGray)
+}
+
+// update recomputes the buckets from the filtered view when its rows, the
+// width or the template keys have changed since the last time.
+func (h *HistogramView) update(width int) {
+	lv := h.logView
+	sevKey, timeKey := lv.severityKey(), lv.timeKey()
+	var rules []*regexp.Regexp
+	h.colors = h.colors[:0]
+	cacheKey := ""
+	if timeKey != nil {
+		cacheKey = timeKey.Name + "|" + timeKey.Layout
+	}
+	if sevKey != nil {
+		cacheKey += "|" + sevKey.Name
+		for _, cw := range sevKey.ColorWhen {
+			reg, err := regexp.Compile(cw.MatchValue)
+			if err != nil {
+				reg = regexp.MustCompile(`$^`)
+			}
+			rules = append(rules, reg)
+			cacheKey += "|" + cw.MatchValue
+			h.colors = append(h.colors, seriesColor(cw.Color))
+		}
+	}
+	h.colors = append(h.colors, tcell.ColorGray)
+	reset := cacheKey != h.cacheKey
+	if reset {
+		h.points = make(map[int64]histogramPoint)
+		h.cacheKey = cacheKey
+	}
+
+	lv.filterLock.RLock()
+	gen := lv.rowsGen
+	wait := histogramRefresh - time.Since(h.updatedAt)
+	if !reset && width == h.width && (gen == h.rowsGen || wait > 0) {
+		lv.filterLock.RUnlock()
+		if gen != h.rowsGen && h.redraw == nil {
+			// draw the latest rows even if nothing else triggers a redraw
+			h.redraw = time.AfterFunc(wait, lv.app.Draw)
+		}
+		return
+	}
+	rows := slices.Clone(lv.rows())
+	lv.filterLock.RUnlock()
+	h.rowsGen, h.width, h.updatedAt = gen, width, time.Now()
+	if h.redraw != nil {
+		h.redraw.Stop()
+		h.redraw = nil
+	}
+
+	if len(h.points) > 2*len(rows)+1000 {
+		first := lv.inSlice.First()
+		for seq := range h.points {
+			if seq < first {
+				delete(h.points, seq)
+			}
+		}
+	}
+	points := make([]aggregate.Point, len(rows))
+	timed := false
+	for i, seq := range rows {
+		p, ok := h.points[seq]
+		if !ok {
+			m, found := lv.inSlice.Get(seq)
+			if !found {
+				continue
+			}
+			p.series = len(rules)
+			if sevKey != nil {
+				v := sevKey.ExtractValue(m)
+				for r, reg := range rules {
+					if reg.MatchString(v) {
+						p.series = r
+						break
+					}
+				}
+			}
+			if timeKey != nil {
+				p.at, _ = timeKey.ExtractTime(m)
+			}
+			p.arrived, _ = lv.inSlice.ArrivedAt(seq)
+			h.points[seq] = p
+		}
+		points[i] = aggregate.Point{At: p.at, Series: p.series}
+		timed = timed || !p.at.IsZero()
+	}
+	if !timed {
+		for i, seq := range rows {
+			points[i].At = h.points[seq].arrived
+		}
+	}
+	h.buckets = aggregate.Timeline(points, len(h.colors), width)
+}
+
+func (h *HistogramView) label() string {
+	if len(h.buckets) == 0 {
+		return "No entries with a time"
+	}
+	first, last := h.buckets[0].From, h.buckets[len(h.buckets)-1].To
+	layout := time.TimeOnly
+	if last.Sub(first) > 24*time.Hour {
+		layout = time.DateTime
+	}
+	if h.selected < 0 {
+		peak := 0
+		for _, b := range h.buckets {
+			peak = max(peak, b.Total)
+		}
+		return fmt.Sprintf("%s → %s  [yellow::b]peak[-::-] %d per %s  [gray]← → select",
+			first.Format(layout), last.Format(layout), peak, bucketWidth(h.buckets[0]))
+	}
+	b := h.buckets[h.selected]
+	return fmt.Sprintf("%s → %s  [yellow::b]%d[-::-] entries",
+		b.From.Format(layout), b.To.Format(layout), b.Total)
+}
+
+func bucketWidth(b aggregate.Bucket) time.Duration {
+	d := b.To.Sub(b.From)
+	switch {
+	case d > time.Second:
+		return d.Round(time.Second)
+	case d > time.Millisecond:
+		return d.Round(time.Millisecond)
+	}
+	return d
+}
+
+// seriesColor picks the most distinctive colour of a color-when rule.
+func seriesColor(c config.Color) tcell.Color {
+	if bg := c.GetBackgroundColor(); bg != tcell.ColorBlack && bg != tcell.ColorDefault {
+		return bg
+	}
+	return c.GetForegroundColor()
+}
+
+// selectBucket selects a bucket and scrolls the table to its first entry.
+func (h *HistogramView) selectBucket(i int) {
+	if len(h.buckets) == 0 {
+		return
+	}
+	h.selected = max(0, min(i, len(h.buckets)-1))
+	if first := h.buckets[h.selected].First; first >= 0 {
+		h.logView.isFollowing = false
+		h.logView.table.Select(first+1, 0)
+		h.logView.updateLineView()
+	}
+}
+
+func (h *HistogramView) InputHandler() func(event *tcell.EventKey, setFocus func(p tview.Primitive)) {
+	return h.WrapInputHandler(func(event *tcell.EventKey, setFocus func(p tview.Primitive)) {
+		switch event.Key() {
+		case tcell.KeyLeft:
+			h.selectBucket(h.selected - 1)
+		case tcell.KeyRight:
+			h.selectBucket(h.selected + 1)
+		case tcell.KeyHome:
+			h.selectBucket(0)
+		case tcell.KeyEnd:
+			h.selectBucket(len(h.buckets) - 1)
+		case tcell.KeyEnter, tcell.KeyEsc:
+			setFocus(h.logView.table)
+		}
+	})
+}
+
+func (h *HistogramView) MouseHandler() func(action tview.MouseAction, event *tcell.EventMouse, setFocus func(p tview.Primitive)) (consumed bool, capture tview.Primitive) {
+	return h.WrapMouseHandler(func(action tview.MouseAction, event *tcell.EventMouse, setFocus func(p tview.Primitive)) (consumed bool, capture tview.Primitive) {
+		x, y := event.Position()
+		if action != tview.MouseLeftClick || !h.InRect(x, y) {
+			return false, nil
+		}
+		ix, _, _, _ := h.GetInnerRect()
+		setFocus(h)
+		h.selectBucket(x - ix)
+		return true, nil
+	})
+}
+
+// severityKey is the first template key with colours by value, if any.
+func (l *LogView) severityKey() *config.Key {
+	for i := range l.config.Keys {
+		if len(l.config.Keys[i].ColorWhen) > 0 {
+			return &l.config.Keys[i]
+		}
+	}
+	return nil
+}
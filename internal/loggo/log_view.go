@@ -28,6 +28,8 @@ import (
 	"sync"
 	"time"
 
+	"github.com/aurc/loggo/internal/buffer"
+	"github.com/aurc/loggo/internal/decoder"
 	"github.com/aurc/loggo/internal/filter"
 
 	"github.com/aurc/loggo/internal/reader"
@@ -42,6 +44,7 @@ type LogView struct {
 	tview.Flex
 	app                *LoggoApp
 	chanReader         reader.Reader
+	decoder            decoder.Chain
 	table              *tview.Table
 	jsonView           *JsonView
 	data               *LogData
@@ -52,13 +55,30 @@ type LogView struct {
 	navMenu            *tview.Flex
 	mainMenu           *tview.Flex
 	filterView         *FilterView
+	histogramView      *HistogramView
+	showHistogram      bool
 	linesView          *tview.TextView
+	retentionView      *tview.TextView
 	followingView      *tview.TextView
 	logFullScreen      bool
 	templateFullScreen bool
-	inSlice            []map[string]any
-	finSlice           []map[string]any
-	filterChannel      chan *filter.Expression
+	inSlice            *buffer.Buffer
+	finSlice           []int64
+	matches            map[int64]bool
+	matchCount         int64
+	highlighting       bool
+	contextSize        int
+	contextRows        map[int64]bool
+	contextLeft        int
+	lastAdded          int64
+	showFilteredIndex  bool
+	sortKey            string
+	sortDesc           bool
+	sorted             []int64
+	pendingSorted      []int64
+	rowsGen            uint64
+	sortValues         map[int64]config.SortValue
+	filterChannel      chan filterRequest
 	filterLock         sync.RWMutex
 	globalCount        int64
 	isFollowing        bool
@@ -69,18 +89,56 @@ type LogView struct {
 }
 
 func NewLogReader(app *LoggoApp, reader reader.Reader) *LogView {
+	lv, decoderErr := newLogView(app, reader, buffer.New(app.options.retention))
+	lv.start(app.options.filter)
+
+	go func() {
+		lv.app.ShowModal(NewSplashScreen(lv.app), 71, 16, tcell.ColorBlack, nil)
+		lv.app.Draw()
+		time.Sleep(2 * time.Second)
+		lv.app.DismissModal(lv.table)
+		lv.app.Draw()
+
+		time.Sleep(10 * time.Millisecond)
+		lv.isFollowing = true
+		lv.app.SetFocus(lv.table)
+		if decoderErr != nil {
+			lv.showDecoderError(decoderErr)
+			lv.app.Draw()
+		}
+	}()
+	return lv
+}
+
+// newLogView builds a view of the entries held in inSlice. When reader isn't
+// nil it streams into inSlice once the view starts, otherwise the buffer is
+// fed by another view.
+func newLogView(app *LoggoApp, reader reader.Reader, inSlice *buffer.Buffer) (*LogView, error) {
 	lv := &LogView{
 		Flex:          *tview.NewFlex(),
 		app:           app,
 		config:        app.Config(),
 		chanReader:    reader,
-		filterChannel: make(chan *filter.Expression, 1),
+		inSlice:       inSlice,
+		matches:       make(map[int64]bool),
+		contextRows:   make(map[int64]bool),
+		sortValues:    make(map[int64]config.SortValue),
+		lastAdded:     -1,
+		filterChannel: make(chan filterRequest, 1),
 		filterLock:    sync.RWMutex{},
 		hideFilter:    true,
 		isFollowing:   true,
 	}
 	lv.makeUIComponents()
 	lv.makeLayouts()
+	var decoderErr error
+	lv.decoder, decoderErr = decoder.ForConfig(lv.config)
+	if reader == nil {
+		if len(lv.config.LastSavedName) > 0 {
+			lv.keyMap = lv.config.KeyMap()
+		}
+		return lv, decoderErr
+	}
 	reader.ErrorNotifier(func(err error) {
 		go func() {
 			time.Sleep(time.Second)
@@ -106,27 +164,46 @@ func NewLogReader(app *LoggoApp, reader reader.Reader) *LogView {
 				lv.app.DismissModal(lv.table)
 			}))
 	})
+	return lv, decoderErr
+}
 
-	go func() {
-
-	}()
-
-	lv.read()
-	lv.filter()
-	lv.filterChannel <- nil
-
-	go func() {
-		lv.app.ShowModal(NewSplashScreen(lv.app), 71, 16, tcell.ColorBlack, nil)
-		lv.app.Draw()
-		time.Sleep(2 * time.Second)
-		lv.app.DismissModal(lv.table)
-		lv.app.Draw()
+// start streams and filters the entries, with the given filter expression
+// applied if not empty.
+func (l *LogView) start(expression string) {
+	l.filterView.SetContext(l.app.options.context)
+	if l.chanReader != nil {
+		l.read()
+	}
+	l.filter()
+	if len(expression) > 0 {
+		l.hideFilter = false
+		l.makeLayouts()
+		l.filterView.SetExpression(expression)
+	} else {
+		l.filterChannel <- filterRequest{}
+	}
+}
 
-		time.Sleep(10 * time.Millisecond)
-		lv.isFollowing = true
-		lv.app.SetFocus(lv.table)
-	}()
-	return lv
+func (l *LogView) showDecoderError(err error) {
+	l.app.ShowPrefabModal(fmt.Sprintf("[yellow::b]Invalid template parser:[-::-]\n"+
+		"Only json and logfmt lines will be decoded."+
+		"\n[::i]%v", err), 50, 12,
+		func(event *tcell.EventKey) *tcell.EventKey {
+			switch event.Key() {
+			case tcell.KeyEnter, tcell.KeyEsc:
+				l.app.DismissModal(l.table)
+				return nil
+			}
+			switch event.Rune() {
+			case 'C', 'c':
+				l.app.DismissModal(l.table)
+				return nil
+			}
+			return event
+		},
+		tview.NewButton("[darkred::bu]C[-::-]ancel").SetSelectedFunc(func() {
+			l.app.DismissModal(l.table)
+		}))
 }
 
 func (l *LogView) makeUIComponents() {
@@ -140,7 +217,10 @@ func (l *LogView) makeUIComponents() {
 		logView: l,
 	}
 	selection := func(row, column int) {
-		if row > 0 && row-1 < len(l.finSlice) {
+		l.filterLock.RLock()
+		entry, ok := l.entryAt(row - 1)
+		l.filterLock.RUnlock()
+		if ok {
 			l.jsonView = NewJsonView(l.app, false,
 				func() {
 					// Toggle full screen func
@@ -149,10 +229,10 @@ func (l *LogView) makeUIComponents() {
 				}, l.makeLayouts)
 			l.jsonView.SetBorder(true).SetTitle("Log Entry")
 			var b []byte
-			if _, ok := l.finSlice[row-1][config.ParseErr]; ok {
-				b = []byte(fmt.Sprintf(`%v`, l.finSlice[row-1][config.TextPayload]))
+			if _, ok := entry[config.ParseErr]; ok {
+				b = []byte(fmt.Sprintf(`%v`, entry[config.TextPayload]))
 			} else {
-				b, _ = json.Marshal(l.finSlice[row-1])
+				b, _ = json.Marshal(entry)
 			}
 			l.jsonView.SetJson(b)
 			l.makeLayoutsWithJsonView()
@@ -168,13 +248,16 @@ func (l *LogView) makeUIComponents() {
 		SetContent(l.data)
 	l.table.
 		SetFocusFunc(func() {
+			l.app.setActive(l)
 			if l.isJsonViewShown() {
 				l.updateBottomBarMenu()
 			}
 		})
 	l.table.SetSelectedFunc(selection).
 		SetBackgroundColor(color.ColorBackgroundField)
+	l.table.SetMouseCapture(l.headerClick)
 	l.table.SetSelectionChangedFunc(func(row, column int) {
+		l.app.syncPanes(l)
 		// stop scrolling!
 		if l.isFollowing {
 			l.isFollowing = false
@@ -199,9 +282,8 @@ func (l *LogView) makeUIComponents() {
 		}
 	})
 
-	l.keyEvents()
-
 	l.linesView = tview.NewTextView().SetDynamicColors(true).SetTextAlign(tview.AlignRight)
+	l.retentionView = tview.NewTextView().SetDynamicColors(true).SetTextAlign(tview.AlignRight)
 	l.followingView = tview.NewTextView().
 		SetRegions(true).
 		SetDynamicColors(true)
@@ -215,13 +297,20 @@ func (l *LogView) makeUIComponents() {
 	l.updateLineView()
 
 	l.filterView = NewFilterView(l.app, func(expression *filter.Expression) {
-		l.rebufferFilter = true
-		l.filterChannel <- expression
-		go func() {
-			time.Sleep(200 * time.Millisecond)
-			l.app.Draw()
-		}()
+		l.refilter(expression, nil)
 	})
+	l.filterView.SetHistory(l.app.filterHistory())
+	l.filterView.SetKeySuggester(l.observedKeys)
+	l.filterView.SetValueSuggester(l.frequentValues)
+	l.histogramView = NewHistogramView(l)
+}
+
+func (l *LogView) toggleHistogram() {
+	l.showHistogram = !l.showHistogram
+	if l.isJsonViewShown() || l.isTemplateViewShown() {
+		return
+	}
+	l.makeLayouts()
 }
 
 func (l *LogView) toggleFilter() {
@@ -237,8 +326,15 @@ func (l *LogView) toggleFilter() {
 }
 
 func (l *LogView) makeLayouts() {
+	var tableContent tview.Primitive = l.table
+	if l.showHistogram {
+		tableContent = tview.NewFlex().SetDirection(tview.FlexRow).
+			AddItem(l.histogramView, histogramHeight, 1, false).
+			AddItem(NewHorizontalSeparator(color.FieldStyle, LineHThin, "", 0), 1, 1, false).
+			AddItem(l.table, 0, 1, true)
+	}
 	mainContent := tview.NewFlex().SetDirection(tview.FlexColumn).
-		AddItem(l.table, 0, 2, true).
+		AddItem(tableContent, 0, 2, true).
 		AddItem(l.navMenu, 26, 1, false)
 
 	l.Flex.Clear().SetDirection(tview.FlexRow)
@@ -0,0 +1,119 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package loggo
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+
+	"github.com/aurc/loggo/internal/export"
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+func (l *LogView) showExport() {
+	formats := export.Formats()
+	labels := make([]string, len(formats))
+	for i, f := range formats {
+		labels[i] = f.Label()
+	}
+	dir, err := os.Getwd()
+	if err != nil {
+		dir, _ = os.UserHomeDir()
+	}
+	fileName := filepath.Join(dir,
+		fmt.Sprintf("loggo-%s.%s", time.Now().Format("20060102-150405"), export.JSONL))
+	format := export.JSONL
+
+	form := tview.NewForm()
+	fileField := tview.NewInputField().
+		SetLabel("File").
+		SetText(fileName)
+	formatField := tview.NewDropDown().
+		SetLabel("Format").
+		SetOptions(labels, func(_ string, index int) {
+			if index < 0 {
+				return
+			}
+			format = formats[index]
+			fileField.SetText(format.WithExt(fileField.GetText()))
+		}).
+		SetCurrentOption(0)
+	form.AddFormItem(fileField).
+		AddFormItem(formatField).
+		AddButton("Export", func() {
+			l.app.DismissModal(l.table)
+			go l.exportFiltered(fileField.GetText(), format)
+		}).
+		AddButton("Cancel", func() {
+			l.app.DismissModal(l.table)
+		})
+	form.SetBorderPadding(1, 0, 1, 1).
+		SetBackgroundColor(tcell.ColorDarkBlue)
+
+	l.app.ShowModal(form, 80, 9, tcell.ColorDarkBlue, func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Key() == tcell.KeyEsc && !formatField.IsOpen() {
+			l.app.DismissModal(l.table)
+			return nil
+		}
+		return event
+	})
+	l.app.SetFocus(form)
+}
+
+// exportFiltered writes the entries currently in the filtered view to
+// fileName. The filtered rows are snapshotted first so the stream isn't held
+// up while the file is written.
+func (l *LogView) exportFiltered(fileName string, format export.Format) {
+	l.filterLock.RLock()
+	seqs := slices.Clone(l.rows())
+	l.filterLock.RUnlock()
+
+	count, err := l.writeExport(fileName, format, seqs)
+	if err != nil {
+		l.app.ShowPopMessage(fmt.Sprintf("[red::b]Export failed:[-::-] %v", err), 4, l.table)
+		return
+	}
+	l.app.ShowPopMessage(fmt.Sprintf("Exported [green::b]%d[-::-] entries to %s", count, fileName), 3, l.table)
+}
+
+func (l *LogView) writeExport(fileName string, format export.Format, seqs []int64) (int, error) {
+	file, err := os.Create(fileName)
+	if err != nil {
+		return 0, err
+	}
+	count, err := export.Write(file, format, l.config.Keys, func(yield func(map[string]any) bool) {
+		for _, seq := range seqs {
+			if m, ok := l.inSlice.Get(seq); ok && !yield(m) {
+				return
+			}
+		}
+	})
+	if cErr := file.Close(); err == nil {
+		err = cErr
+	}
+	return count, err
+}
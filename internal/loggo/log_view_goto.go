@@ -0,0 +1,126 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package loggo
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+func (l *LogView) showGoToLine() {
+	form := tview.NewForm()
+	lineField := tview.NewInputField().
+		SetLabel("Line").
+		SetFieldWidth(12).
+		SetAcceptanceFunc(tview.InputFieldInteger)
+	jump := func() {
+		l.app.DismissModal(l.table)
+		n, err := strconv.ParseInt(lineField.GetText(), 10, 64)
+		if err != nil {
+			return
+		}
+		l.goToLine(n)
+	}
+	lineField.SetDoneFunc(func(key tcell.Key) {
+		if key == tcell.KeyEnter {
+			jump()
+		}
+	})
+	form.AddFormItem(lineField).
+		AddButton("Go", jump).
+		AddButton("Cancel", func() {
+			l.app.DismissModal(l.table)
+		})
+	form.SetBorderPadding(1, 0, 1, 1).
+		SetBackgroundColor(tcell.ColorDarkBlue)
+
+	l.app.ShowModal(form, 40, 7, tcell.ColorDarkBlue, func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Key() == tcell.KeyEsc {
+			l.app.DismissModal(l.table)
+			return nil
+		}
+		return event
+	})
+	l.app.SetFocus(lineField)
+}
+
+// goToLine selects the entry with the given original line number (1-based,
+// in stream order). When the local filter hides it, the filter is cleared
+// first and the entry is selected once every entry has been filtered again.
+// It must be called from the event loop.
+func (l *LogView) goToLine(line int64) {
+	seq := line - 1
+	if seq < l.inSlice.First() {
+		l.app.ShowPopMessage(fmt.Sprintf("Line %d is no longer retained", line), 3, l.table)
+		return
+	}
+	if seq >= l.inSlice.Next() {
+		l.app.ShowPopMessage(fmt.Sprintf("Line %d hasn't been streamed yet", line), 3, l.table)
+		return
+	}
+	if l.selectSeq(seq) {
+		return
+	}
+	l.filterView.expressionField.SetText("")
+	l.filterView.applied = ""
+	l.refilter(nil, func() {
+		l.app.QueueUpdateDraw(func() {
+			if !l.selectSeq(seq) {
+				l.app.ShowPopMessage(fmt.Sprintf("Line %d could not be found", line), 3, l.table)
+			}
+		})
+	})
+}
+
+// selectSeq selects the row showing the entry with the given sequence number,
+// reporting whether it is in the filtered view. It must be called from the
+// event loop.
+func (l *LogView) selectSeq(seq int64) bool {
+	l.filterLock.RLock()
+	found := -1
+	for i, s := range l.rows() {
+		if s == seq {
+			found = i
+			break
+		}
+	}
+	l.filterLock.RUnlock()
+	if found < 0 {
+		return false
+	}
+	l.isFollowing = false
+	l.table.Select(found+1, 0)
+	l.updateLineView()
+	return true
+}
+
+// toggleFilteredIndex shows or hides the position of each row in the
+// filtered view next to its original line number.
+func (l *LogView) toggleFilteredIndex() {
+	l.filterLock.Lock()
+	l.showFilteredIndex = !l.showFilteredIndex
+	l.filterLock.Unlock()
+}
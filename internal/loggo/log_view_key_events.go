@@ -29,61 +29,129 @@ import (
 	"github.com/rivo/tview"
 )
 
-func (l *LogView) keyEvents() {
-	l.app.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
-		if l.app.inputCapture != nil {
-			return l.app.inputCapture(event)
+// keyEvents handles the keys of the split panes, passing the others on to the
+// active pane unless a modal is shown.
+func (a *LoggoApp) keyEvents() {
+	a.SetInputCapture(func(event *tcell.EventKey) *tcell.EventKey {
+		if a.inputCapture != nil {
+			return a.inputCapture(event)
 		}
-		switch event.Key() {
-		case tcell.KeyCtrlN:
-			l.toggleSelectionMouse()
+		if event = a.splitKeyEvents(event); event == nil {
 			return nil
-		case tcell.KeyCtrlA:
-			go func() {
-				l.showAbout()
-			}()
-			return nil
-		case tcell.KeyCtrlT:
-			l.makeLayoutsWithTemplateView()
+		}
+		return a.active.handleKey(event)
+	})
+}
+
+func (l *LogView) handleKey(event *tcell.EventKey) *tcell.EventKey {
+	switch event.Key() {
+	case tcell.KeyCtrlN:
+		l.toggleSelectionMouse()
+		return nil
+	case tcell.KeyCtrlA:
+		go func() {
+			l.showAbout()
+		}()
+		return nil
+	case tcell.KeyCtrlT:
+		l.makeLayoutsWithTemplateView()
+		return nil
+	case tcell.KeyCtrlE:
+		if _, ok := l.app.app.GetFocus().(*tview.InputField); !ok {
+			l.showExport()
 			return nil
-		case tcell.KeyCtrlSpace:
-			l.toggledFollowing()
+		}
+		return event
+	case tcell.KeyCtrlSpace:
+		l.toggledFollowing()
+		return nil
+	case tcell.KeyTAB:
+		if l.isJsonViewShown() {
+			if l.jsonView.textView.HasFocus() {
+				l.app.SetFocus(l.table)
+				go func() {
+					time.Sleep(time.Millisecond)
+					l.updateBottomBarMenu()
+				}()
+			} else {
+				l.app.SetFocus(l.jsonView.textView)
+				go func() {
+					time.Sleep(time.Millisecond)
+					l.updateBottomBarMenu()
+				}()
+			}
 			return nil
-		case tcell.KeyTAB:
-			if l.isJsonViewShown() {
-				if l.jsonView.textView.HasFocus() {
-					l.app.SetFocus(l.table)
-					go func() {
-						time.Sleep(time.Millisecond)
-						l.updateBottomBarMenu()
-					}()
-				} else {
-					l.app.SetFocus(l.jsonView.textView)
-					go func() {
-						time.Sleep(time.Millisecond)
-						l.updateBottomBarMenu()
-					}()
-				}
+		}
+		if l.showHistogram && !l.isTemplateViewShown() {
+			if l.table.HasFocus() {
+				l.app.SetFocus(l.histogramView)
+				return nil
+			} else if l.histogramView.HasFocus() {
+				l.app.SetFocus(l.table)
 				return nil
 			}
-			return event
 		}
-		prim := l.app.app.GetFocus()
-		if _, ok := prim.(*tview.InputField); ok {
-			return event
+		return event
+	}
+	prim := l.app.app.GetFocus()
+	if _, ok := prim.(*tview.InputField); ok {
+		return event
+	}
+	switch event.Rune() {
+	case ':':
+		l.toggleFilter()
+		return nil
+	case 'n', 'N':
+		if prim == l.table {
+			if event.Rune() == 'n' {
+				l.selectMatch(1)
+			} else {
+				l.selectMatch(-1)
+			}
+			return nil
 		}
-		switch event.Rune() {
-		case ':':
-			l.toggleFilter()
+	case 'L':
+		if prim == l.table {
+			l.showGoToLine()
 			return nil
 		}
-		if prim == l.table && l.isJsonViewShown() {
-			switch event.Rune() {
-			case 'f', '`', 's', 'r', 'g', 'G', 'w', 'x':
-				return l.jsonView.textView.GetInputCapture()(event)
-			}
+	case '#':
+		if prim == l.table {
+			l.toggleFilteredIndex()
+			return nil
+		}
+	case 'H':
+		if prim == l.table || prim == l.histogramView {
+			l.toggleHistogram()
+			return nil
+		}
+	case 'S':
+		if prim == l.table {
+			l.showStats()
+			return nil
+		}
+	case 'a':
+		if prim == l.table {
+			l.showTopValues()
+			return nil
 		}
+	case 'o':
+		if prim == l.table {
+			l.showSort()
+			return nil
+		}
+	case 'O':
+		if prim == l.table {
+			l.sortBy("", false)
+			return nil
+		}
+	}
+	if prim == l.table && l.isJsonViewShown() {
+		switch event.Rune() {
+		case 'f', '`', 's', 'r', 'g', 'G', 'w', 'x':
+			return l.jsonView.textView.GetInputCapture()(event)
+		}
+	}
 
-		return event
-	})
+	return event
 }
@@ -25,6 +25,7 @@ package loggo
 import (
 	"fmt"
 	"runtime"
+	"strings"
 
 	"github.com/aurc/loggo/internal/color"
 	"github.com/gdamore/tcell/v2"
@@ -36,8 +37,17 @@ const (
 	selectionMouseDisabledMenu = `[yellow::b] ^n      [-::u]["1"]Enable Mouse[""]`
 	templateMenu               = `[yellow::b] ^t      [-::u]["1"]Template[""]`
 	localFilterMenu            = `[yellow::b] :       [-::u]["1"]Local Filter[""]`
+	exportMenu                 = `[yellow::b] ^e      [-::u]["1"]Export[""]`
+	topValuesMenu              = `[yellow::b] a       [-::u]["1"]Top Values[""]`
+	histogramMenu              = `[yellow::b] H       [-::u]["1"]Histogram[""]`
+	statsMenu                  = `[yellow::b] S       [-::u]["1"]Number Stats[""]`
 	viewEntryMenu              = `[yellow::b] Enter[-::-]   View Entry`
 	navigateMenu               = `[yellow::b] ↓ ← ↑ →[-::-] Navigate`
+	nextMatchMenu              = `[yellow::b] n N     [-::-]Next/Prev Match`
+	goToLineMenu               = `[yellow::b] L       [-::u]["1"]Go to Line[""]`
+	lineNumbersMenu            = `[yellow::b] #       [-::u]["1"]Filtered Index[""]`
+	sortMenu                   = `[yellow::b] o       [-::u]["1"]Sort By[""]`
+	arrivalOrderMenu           = `[yellow::b] O       [-::u]["1"]Arrival Order[""]`
 	goTopMenu                  = `[yellow::b] g       [-::u]["1"]Top[""]`
 	goBottomMenu               = `[yellow::b] G       [-::u]["1"]Bottom[""]`
 	pageUpMenu                 = `[yellow::b] ^b      [-::u]["1"]Pg Up[""]`
@@ -80,6 +90,26 @@ func (l *LogView) populateMenu() {
 			SetText(localFilterMenu), func() {
 			l.toggleFilter()
 		}), 1, 2, false).
+		AddItem(l.textViewMenuControl(tview.NewTextView().
+			SetDynamicColors(true).SetRegions(true).
+			SetText(exportMenu), func() {
+			l.showExport()
+		}), 1, 2, false).
+		AddItem(l.textViewMenuControl(tview.NewTextView().
+			SetDynamicColors(true).SetRegions(true).
+			SetText(topValuesMenu), func() {
+			l.showTopValues()
+		}), 1, 2, false).
+		AddItem(l.textViewMenuControl(tview.NewTextView().
+			SetDynamicColors(true).SetRegions(true).
+			SetText(histogramMenu), func() {
+			l.toggleHistogram()
+		}), 1, 2, false).
+		AddItem(l.textViewMenuControl(tview.NewTextView().
+			SetDynamicColors(true).SetRegions(true).
+			SetText(statsMenu), func() {
+			l.showStats()
+		}), 1, 2, false).
 		//////////////////////////////////////////////////////////////////
 		// Navigation Menu
 		//////////////////////////////////////////////////////////////////
@@ -91,12 +121,35 @@ func (l *LogView) populateMenu() {
 		AddItem(tview.NewTextView().
 			SetDynamicColors(true).
 			SetText(navigateMenu), 1, 3, false).
+		AddItem(tview.NewTextView().
+			SetDynamicColors(true).
+			SetText(nextMatchMenu), 1, 3, false).
+		AddItem(l.textViewMenuControl(tview.NewTextView().
+			SetDynamicColors(true).SetRegions(true).
+			SetText(goToLineMenu), func() {
+			l.showGoToLine()
+		}), 1, 2, false).
+		AddItem(l.textViewMenuControl(tview.NewTextView().
+			SetDynamicColors(true).SetRegions(true).
+			SetText(lineNumbersMenu), func() {
+			l.toggleFilteredIndex()
+		}), 1, 2, false).
+		AddItem(l.textViewMenuControl(tview.NewTextView().
+			SetDynamicColors(true).SetRegions(true).
+			SetText(sortMenu), func() {
+			l.showSort()
+		}), 1, 2, false).
+		AddItem(l.textViewMenuControl(tview.NewTextView().
+			SetDynamicColors(true).SetRegions(true).
+			SetText(arrivalOrderMenu), func() {
+			l.sortBy("", false)
+		}), 1, 2, false).
 		AddItem(l.textViewMenuControl(tview.NewTextView().
 			SetDynamicColors(true).SetRegions(true).
 			SetText(goTopMenu), func() {
 			l.isFollowing = false
 			l.table.ScrollToBeginning()
-			if len(l.inSlice) > 1 {
+			if l.table.GetRowCount() > 1 {
 				go l.table.Select(1, 0)
 			}
 		}), 1, 1, false).
@@ -105,7 +158,7 @@ func (l *LogView) populateMenu() {
 			SetText(goBottomMenu), func() {
 			l.isFollowing = false
 			l.table.ScrollToEnd()
-			go l.table.Select(len(l.inSlice), 0)
+			go l.table.Select(l.table.GetRowCount()-1, 0)
 		}), 1, 2, false).
 		AddItem(l.textViewMenuControl(tview.NewTextView().
 			SetDynamicColors(true).SetRegions(true).
@@ -153,6 +206,7 @@ func (l *LogView) populateMenu() {
 		}), 1, 1, false).
 		AddItem(NewHorizontalSeparator(sepStyle, LineHThick, "", sepForeground), 1, 2, false).
 		AddItem(tview.NewBox(), 0, 1, false).
+		AddItem(l.retentionView, 1, 1, false).
 		AddItem(l.linesView, 1, 1, false)
 
 	l.mainMenu = tview.NewFlex().SetDirection(tview.FlexColumn)
@@ -235,6 +289,17 @@ func (l *LogView) updateLineView() {
 				Sprintf(`[green::b]%d[yellow::-] lines`,
 					l.globalCount))
 	}
+	var retention []string
+	if l.highlighting {
+		retention = append(retention, fmt.Sprintf(`[green::b]%d[yellow::-] matches`, l.matchCount))
+	}
+	if spilled := l.inSlice.Spilled(); spilled > 0 {
+		retention = append(retention, fmt.Sprintf(`[blue::b]%d[yellow::-] on disk`, spilled))
+	}
+	if evicted := l.inSlice.Evicted(); evicted > 0 {
+		retention = append(retention, fmt.Sprintf(`[red::b]%d[yellow::-] evicted`, evicted))
+	}
+	l.retentionView.SetText(strings.Join(retention, ", "))
 	if l.isFollowing {
 		l.followingView.SetText(autoScrollOnMenu)
 	} else {
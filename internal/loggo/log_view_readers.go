@@ -23,8 +23,8 @@ THE SOFTWARE.
 package loggo
 
 import (
-	"encoding/json"
 	"fmt"
+	"sort"
 	"time"
 
 	"github.com/gdamore/tcell/v2"
@@ -35,6 +35,23 @@ import (
 	"github.com/rivo/tview"
 )
 
+// dividerRow marks, in the filtered view, a gap between groups of entries
+// shown with their context.
+const dividerRow = int64(-1)
+
+// filterRequest asks the filter goroutine to go through the entries again with
+// exp. done, if set, is called once every streamed entry has been filtered.
+type filterRequest struct {
+	exp  *filter.Expression
+	done func()
+}
+
+const (
+	suggestionSampleSize = 5000
+	keySampleSize        = 200
+	maxValueSuggestions  = 10
+)
+
 func (l *LogView) read() {
 	go func() {
 		if err := l.chanReader.StreamInto(); err != nil {
@@ -62,13 +79,7 @@ func (l *LogView) read() {
 			for {
 				t := <-l.chanReader.ChanReader()
 				if len(t) > 0 {
-					m := make(map[string]any)
-					err := json.Unmarshal([]byte(t), &m)
-					if err != nil {
-						m[config.ParseErr] = err.Error()
-						m[config.TextPayload] = t
-					}
-					l.inSlice = append(l.inSlice, m)
+					l.inSlice.Append(l.decoder.Decode(t), len(t))
 				}
 			}
 		}
@@ -80,30 +91,42 @@ func (l *LogView) processSampleForConfig(sampling []map[string]any) {
 		return
 	}
 	l.config, l.keyMap = config.MakeConfigFromSample(sampling, l.config.Keys...)
-	l.app.config = l.config
+	// the split pane keeps its own config, the app's follows the main pane
+	if l == l.app.logView {
+		l.app.config = l.config
+	}
 }
 
 func (l *LogView) filter() {
 	go func() {
 		for {
 			l.rebufferFilter = false
-			exp := <-l.filterChannel
-			l.clearFilterBuffer()
+			req := <-l.filterChannel
+			exp := req.exp
+			l.clearFilterBuffer(exp, l.filterView.Highlight(), l.filterView.Context())
 			l.globalCount = 0
 			l.updateLineView()
 			l.app.Draw()
-			for i := 0; ; {
+			for i := l.inSlice.First(); ; {
 				lastUpdate := time.Now().Add(-time.Minute)
 				if l.rebufferFilter {
 					break
 				}
-				size := len(l.inSlice)
-				if i < size {
+				// entries evicted before being filtered are skipped
+				if first := l.inSlice.First(); i < first {
+					i = first
+				}
+				if i < l.inSlice.Next() {
 					if err := l.filterLine(exp, i); err != nil {
 						break
 					}
 					i++
 				} else {
+					l.flushSorted()
+					if req.done != nil {
+						req.done()
+						req.done = nil
+					}
 					time.Sleep(100 * time.Millisecond)
 					continue
 				}
@@ -120,31 +143,91 @@ func (l *LogView) filter() {
 	}()
 }
 
-func (l *LogView) clearFilterBuffer() {
+// refilter restarts the filtering of every entry with the given expression,
+// calling done, if not nil, once it has caught up with the stream.
+func (l *LogView) refilter(exp *filter.Expression, done func()) {
+	l.rebufferFilter = true
+	l.filterChannel <- filterRequest{exp: exp, done: done}
+	go func() {
+		time.Sleep(200 * time.Millisecond)
+		l.app.Draw()
+	}()
+}
+
+func (l *LogView) clearFilterBuffer(exp *filter.Expression, highlight bool, context int) {
 	l.filterLock.Lock()
 	defer l.filterLock.Unlock()
 	l.finSlice = l.finSlice[:0]
+	l.rowsGen++
+	l.highlighting = exp != nil && highlight
+	l.matches = make(map[int64]bool)
+	l.matchCount = 0
+	l.contextSize = 0
+	if exp != nil && !highlight {
+		l.contextSize = context
+	}
+	l.contextRows = make(map[int64]bool)
+	l.contextLeft = 0
+	l.lastAdded = -1
+	l.sorted = l.sorted[:0]
+	l.pendingSorted = l.pendingSorted[:0]
+	l.sortValues = make(map[int64]config.SortValue)
 }
 
 func (l *LogView) sampleAndCount() {
 	if len(l.config.LastSavedName) == 0 {
+		from := 0
 		if len(l.finSlice) > 20 {
-			l.processSampleForConfig(l.finSlice[len(l.finSlice)-20:])
-		} else {
-			l.processSampleForConfig(l.finSlice)
+			from = len(l.finSlice) - 20
 		}
+		sample := make([]map[string]any, 0, len(l.finSlice)-from)
+		for i := from; i < len(l.finSlice); i++ {
+			if m, ok := l.inSlice.Get(l.finSlice[i]); ok {
+				sample = append(sample, m)
+			}
+		}
+		l.processSampleForConfig(sample)
 	}
 	l.updateLineView()
 }
 
-func (l *LogView) filterLine(e *filter.Expression, index int) error {
+// entryAt returns the entry at the given row index of the filtered view. The
+// caller must hold the filterLock.
+func (l *LogView) entryAt(index int) (map[string]any, bool) {
+	rows := l.rows()
+	if index < 0 || index >= len(rows) {
+		return nil, false
+	}
+	return l.inSlice.Get(rows[index])
+}
+
+// trimEvicted drops filtered rows whose entries were evicted from the buffer.
+// The caller must hold the filterLock.
+func (l *LogView) trimEvicted() {
+	first := l.inSlice.First()
+	i := 0
+	for i < len(l.finSlice) && l.finSlice[i] < first {
+		delete(l.matches, l.finSlice[i])
+		delete(l.contextRows, l.finSlice[i])
+		i++
+	}
+	if i > 0 {
+		l.finSlice = l.finSlice[i:]
+		l.rowsGen++
+		l.trimSorted(first)
+	}
+}
+
+func (l *LogView) filterLine(e *filter.Expression, seq int64) error {
 	l.filterLock.Lock()
 	defer l.filterLock.Unlock()
-	row := l.inSlice[index]
+	l.trimEvicted()
+	row, ok := l.inSlice.Get(seq)
+	if !ok {
+		return nil
+	}
 	if e == nil {
-		l.finSlice = append(l.finSlice, row)
-		l.globalCount++
-		l.sampleAndCount()
+		l.addRow(seq)
 		return nil
 	}
 	a, err := e.Apply(row, l.keyMap)
@@ -168,13 +251,170 @@ func (l *LogView) filterLine(e *filter.Expression, index int) error {
 			tview.NewButton("[darkred::bu]C[-::-]ancel").SetSelectedFunc(func() {
 				l.app.DismissModal(l.table)
 			}))
-		l.filterChannel <- nil
+		l.filterChannel <- filterRequest{}
 		return err
 	}
+	if l.contextSize > 0 {
+		l.appendWithContext(seq, a)
+		return nil
+	}
+	if l.highlighting {
+		// every row is kept, matches are only highlighted
+		if a {
+			l.matches[seq] = true
+			l.matchCount++
+		}
+		a = true
+	}
 	if a {
-		l.finSlice = append(l.finSlice, row)
-		l.globalCount++
-		l.sampleAndCount()
+		l.addRow(seq)
 	}
 	return nil
 }
+
+// addRow appends an entry to the filtered view. The caller must hold the
+// filterLock.
+func (l *LogView) addRow(seq int64) {
+	l.finSlice = append(l.finSlice, seq)
+	l.rowsGen++
+	l.insertSorted(seq)
+	l.globalCount++
+	l.sampleAndCount()
+}
+
+// appendWithContext adds a matching entry to the filtered view along with the
+// contextSize entries preceding and following it. Non-contiguous groups of
+// entries are separated by a divider row.
+func (l *LogView) appendWithContext(seq int64, matched bool) {
+	if !matched {
+		if l.contextLeft > 0 {
+			l.contextLeft--
+			l.appendRow(seq, true)
+		}
+		return
+	}
+	from := seq - int64(l.contextSize)
+	if from <= l.lastAdded {
+		from = l.lastAdded + 1
+	}
+	for s := from; s < seq; s++ {
+		if _, ok := l.inSlice.Get(s); ok {
+			l.appendRow(s, true)
+		}
+	}
+	l.appendRow(seq, false)
+	l.contextLeft = l.contextSize
+}
+
+func (l *LogView) appendRow(seq int64, isContext bool) {
+	if l.lastAdded >= 0 && seq != l.lastAdded+1 {
+		l.finSlice = append(l.finSlice, dividerRow)
+	}
+	if isContext {
+		l.contextRows[seq] = true
+	}
+	l.lastAdded = seq
+	l.addRow(seq)
+}
+
+// isContext tells whether the row at the given index of the filtered view is
+// shown as context of a match. The caller must hold the filterLock.
+func (l *LogView) isContext(index int) bool {
+	rows := l.rows()
+	return l.contextSize > 0 && index >= 0 && index < len(rows) && l.contextRows[rows[index]]
+}
+
+// isDivider tells whether the row at the given index of the filtered view
+// separates groups of context. The caller must hold the filterLock.
+func (l *LogView) isDivider(index int) bool {
+	rows := l.rows()
+	return index >= 0 && index < len(rows) && rows[index] == dividerRow
+}
+
+// isMatch tells whether the row at the given index of the filtered view is
+// highlighted. The caller must hold the filterLock.
+func (l *LogView) isMatch(index int) bool {
+	rows := l.rows()
+	return l.highlighting && index >= 0 && index < len(rows) && l.matches[rows[index]]
+}
+
+// selectMatch selects the next (step > 0) or previous (step < 0) highlighted
+// row from the current selection.
+func (l *LogView) selectMatch(step int) {
+	r, _ := l.table.GetSelection()
+	l.filterLock.RLock()
+	found := -1
+	if l.highlighting {
+		rows := l.rows()
+		for i := r - 1 + step; i >= 0 && i < len(rows); i += step {
+			if l.matches[rows[i]] {
+				found = i
+				break
+			}
+		}
+	}
+	l.filterLock.RUnlock()
+	if found < 0 {
+		return
+	}
+	l.isFollowing = false
+	l.table.Select(found+1, 0)
+	l.updateLineView()
+}
+
+// frequentValues returns the values of key most often seen in the latest
+// streamed entries, most frequent first.
+func (l *LogView) frequentValues(key string) []string {
+	k := config.Key{Name: key}
+	counts := make(map[string]int)
+	first := l.inSlice.First()
+	for seq, n := l.inSlice.Next()-1, 0; seq >= first && n < suggestionSampleSize; seq, n = seq-1, n+1 {
+		if m, ok := l.inSlice.Get(seq); ok {
+			if v := k.ExtractValue(m); len(v) > 0 {
+				counts[v]++
+			}
+		}
+	}
+	values := make([]string, 0, len(counts))
+	for v := range counts {
+		values = append(values, v)
+	}
+	sort.Slice(values, func(i, j int) bool {
+		if counts[values[i]] != counts[values[j]] {
+			return counts[values[i]] > counts[values[j]]
+		}
+		return values[i] < values[j]
+	})
+	if len(values) > maxValueSuggestions {
+		values = values[:maxValueSuggestions]
+	}
+	return values
+}
+
+// observedKeys returns the (slash separated) paths of the values found in the
+// latest streamed entries, sorted.
+func (l *LogView) observedKeys() []string {
+	seen := make(map[string]bool)
+	var walk func(prefix string, m map[string]any)
+	walk = func(prefix string, m map[string]any) {
+		for k, v := range m {
+			if nested, ok := v.(map[string]any); ok {
+				walk(prefix+k+"/", nested)
+			} else if k != config.ParseErr {
+				seen[prefix+k] = true
+			}
+		}
+	}
+	first := l.inSlice.First()
+	for seq, n := l.inSlice.Next()-1, 0; seq >= first && n < keySampleSize; seq, n = seq-1, n+1 {
+		if m, ok := l.inSlice.Get(seq); ok {
+			walk("", m)
+		}
+	}
+	keys := make([]string, 0, len(seen))
+	for k := range seen {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
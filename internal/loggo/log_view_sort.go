@@ -0,0 +1,269 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package loggo
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+
+	"github.com/aurc/loggo/internal/char"
+	"github.com/aurc/loggo/internal/config"
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+// minSortBatch and sortBatchRatio size the batches of newly filtered entries
+// merged into the sorted view: at least minSortBatch entries, or a
+// sortBatchRatio-th of the view, so each entry costs a constant amortised
+// share of a merge.
+const (
+	minSortBatch   = 64
+	sortBatchRatio = 8
+)
+
+// rows returns the sequence numbers of the filtered view in display order:
+// arrival order unless sorted by a column. The caller must hold the
+// filterLock.
+func (l *LogView) rows() []int64 {
+	if len(l.sortKey) > 0 {
+		return l.sorted
+	}
+	return l.finSlice
+}
+
+// sortBy orders the filtered view by the given template key. An empty key
+// restores the arrival order.
+func (l *LogView) sortBy(key string, desc bool) {
+	l.filterLock.Lock()
+	l.sortKey, l.sortDesc = key, desc
+	l.rowsGen++
+	l.sorted = l.sorted[:0]
+	l.pendingSorted = l.pendingSorted[:0]
+	l.sortValues = make(map[int64]config.SortValue)
+	if len(key) > 0 {
+		for _, seq := range l.finSlice {
+			if seq != dividerRow {
+				l.sorted = append(l.sorted, seq)
+				l.sortValues[seq] = l.sortValue(seq)
+			}
+		}
+		slices.SortFunc(l.sorted, l.compareRows)
+	}
+	l.filterLock.Unlock()
+	l.isFollowing = false
+	l.table.ScrollToBeginning()
+	if l.table.GetRowCount() > 1 {
+		l.table.Select(1, 0)
+	}
+	l.updateLineView()
+}
+
+// cycleSort moves the sort of a column from ascending to descending and then
+// back to arrival order.
+func (l *LogView) cycleSort(key string) {
+	switch {
+	case l.sortKey != key:
+		l.sortBy(key, false)
+	case !l.sortDesc:
+		l.sortBy(key, true)
+	default:
+		l.sortBy("", false)
+	}
+}
+
+// sortKeyConfig returns the template key the view is sorted by, if it is still
+// part of the template.
+func (l *LogView) sortKeyConfig() (*config.Key, bool) {
+	for i := range l.config.Keys {
+		if l.config.Keys[i].Name == l.sortKey {
+			return &l.config.Keys[i], true
+		}
+	}
+	return nil, false
+}
+
+func (l *LogView) sortValue(seq int64) config.SortValue {
+	k, ok := l.sortKeyConfig()
+	if !ok {
+		return config.SortValue{}
+	}
+	m, _ := l.inSlice.Get(seq)
+	return k.SortValue(m)
+}
+
+// compareRows orders entries by their sort value, ties are kept in arrival
+// order. The caller must hold the filterLock.
+func (l *LogView) compareRows(a, b int64) int {
+	c := l.sortValues[a].Compare(l.sortValues[b])
+	if l.sortDesc {
+		c = -c
+	}
+	if c == 0 {
+		return cmp.Compare(a, b)
+	}
+	return c
+}
+
+// insertSorted queues a newly filtered entry for the sorted view, merging the
+// queue once it makes a batch. The caller must hold the filterLock.
+func (l *LogView) insertSorted(seq int64) {
+	if len(l.sortKey) == 0 {
+		return
+	}
+	l.sortValues[seq] = l.sortValue(seq)
+	l.pendingSorted = append(l.pendingSorted, seq)
+	if len(l.pendingSorted) >= max(minSortBatch, len(l.sorted)/sortBatchRatio) {
+		l.mergeSorted()
+	}
+}
+
+// flushSorted merges the queued entries into the sorted view, so they show up
+// once the stream goes quiet.
+func (l *LogView) flushSorted() {
+	l.filterLock.Lock()
+	defer l.filterLock.Unlock()
+	l.mergeSorted()
+}
+
+// mergeSorted sorts the queued entries and merges them into the sorted view.
+// The caller must hold the filterLock.
+func (l *LogView) mergeSorted() {
+	if len(l.pendingSorted) == 0 {
+		return
+	}
+	slices.SortFunc(l.pendingSorted, l.compareRows)
+	merged := make([]int64, 0, len(l.sorted)+len(l.pendingSorted))
+	i, j := 0, 0
+	for i < len(l.sorted) && j < len(l.pendingSorted) {
+		if l.compareRows(l.pendingSorted[j], l.sorted[i]) < 0 {
+			merged = append(merged, l.pendingSorted[j])
+			j++
+		} else {
+			merged = append(merged, l.sorted[i])
+			i++
+		}
+	}
+	merged = append(merged, l.sorted[i:]...)
+	l.sorted = append(merged, l.pendingSorted[j:]...)
+	l.rowsGen++
+	l.pendingSorted = l.pendingSorted[:0]
+}
+
+// trimSorted drops evicted entries from the sorted view. The caller must hold
+// the filterLock.
+func (l *LogView) trimSorted(first int64) {
+	if len(l.sortKey) == 0 {
+		return
+	}
+	evicted := func(seq int64) bool {
+		if seq < first {
+			delete(l.sortValues, seq)
+			return true
+		}
+		return false
+	}
+	l.sorted = slices.DeleteFunc(l.sorted, evicted)
+	l.rowsGen++
+	l.pendingSorted = slices.DeleteFunc(l.pendingSorted, evicted)
+}
+
+// sortIndicator returns the symbol shown in the header of the column the view
+// is sorted by.
+func (l *LogView) sortIndicator(key string) string {
+	if l.sortKey != key {
+		return ""
+	}
+	if l.sortDesc {
+		return char.SymSortDesc
+	}
+	return char.SymSortAsc
+}
+
+// headerClick sorts by the column whose header was clicked.
+func (l *LogView) headerClick(action tview.MouseAction, event *tcell.EventMouse) (tview.MouseAction, *tcell.EventMouse) {
+	if action != tview.MouseLeftClick {
+		return action, event
+	}
+	row, column := l.table.CellAt(event.Position())
+	if row != 0 || column < 1 || column > len(l.config.Keys) {
+		return action, event
+	}
+	l.cycleSort(l.config.Keys[column-1].Name)
+	return tview.MouseConsumed, nil
+}
+
+func (l *LogView) showSort() {
+	if len(l.config.Keys) == 0 {
+		return
+	}
+	list := tview.NewList().
+		SetSecondaryTextColor(tcell.ColorLightGrey).
+		SetSelectedFocusOnly(false)
+	list.SetBackgroundColor(tcell.ColorDarkBlue)
+	current := 0
+	for i, k := range l.config.Keys {
+		name := k.Name
+		if ind := l.sortIndicator(k.Name); len(ind) > 0 {
+			name = fmt.Sprintf("%s %s", name, ind)
+			current = i
+		}
+		list.AddItem(name, string(k.Type), 0, func() {
+			l.app.DismissModal(l.table)
+			l.sortBy(k.Name, false)
+		})
+	}
+	list.SetCurrentItem(current)
+	help := tview.NewTextView().
+		SetDynamicColors(true).
+		SetText(`[yellow::b]Enter[-::-] Ascending  [yellow::b]d[-::-] Descending  [yellow::b]O[-::-] Arrival  [yellow::b]Esc[-::-] Close`)
+	help.SetBackgroundColor(tcell.ColorDarkBlue)
+	content := tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(list, 0, 1, true).
+		AddItem(help, 1, 1, false)
+	content.SetBorderPadding(0, 0, 1, 1).
+		SetBackgroundColor(tcell.ColorDarkBlue)
+
+	height := 2*len(l.config.Keys) + 3
+	if height > 20 {
+		height = 20
+	}
+	l.app.ShowModal(content, 70, height, tcell.ColorDarkBlue, func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Key() == tcell.KeyEsc {
+			l.app.DismissModal(l.table)
+			return nil
+		}
+		switch event.Rune() {
+		case 'd':
+			l.app.DismissModal(l.table)
+			l.sortBy(l.config.Keys[list.GetCurrentItem()].Name, true)
+			return nil
+		case 'O':
+			l.app.DismissModal(l.table)
+			l.sortBy("", false)
+			return nil
+		}
+		return event
+	})
+	l.app.SetFocus(list)
+}
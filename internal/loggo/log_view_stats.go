@@ -0,0 +1,126 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package loggo
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/aurc/loggo/internal/aggregate"
+	"github.com/aurc/loggo/internal/config"
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+const (
+	statsBins     = 10
+	statsBarWidth = 40
+)
+
+func (l *LogView) showStats() {
+	var keys []string
+	for _, k := range l.config.Keys {
+		if k.Type == config.TypeNumber {
+			keys = append(keys, k.Name)
+		}
+	}
+	if len(keys) == 0 {
+		l.app.ShowPopMessage("No number keys in the template, set a key's type to number first", 3, l.table)
+		return
+	}
+	result := tview.NewTextView().
+		SetDynamicColors(true)
+	result.SetBackgroundColor(tcell.ColorDarkBlue)
+	keyField := tview.NewDropDown().
+		SetLabel("Key ").
+		SetOptions(keys, func(key string, index int) {
+			if index < 0 {
+				return
+			}
+			result.SetText("Computing...")
+			k := *l.keyConfig(key)
+			go func() {
+				st := aggregate.Stats(k, l.filteredEntries(), statsBins)
+				l.app.QueueUpdateDraw(func() {
+					result.SetText(formatStats(st))
+				})
+			}()
+		})
+	keyField.SetBackgroundColor(tcell.ColorDarkBlue)
+	help := tview.NewTextView().
+		SetDynamicColors(true).
+		SetText(`[yellow::b]Enter[-::-] Change key  [yellow::b]Esc[-::-] Close`)
+	help.SetBackgroundColor(tcell.ColorDarkBlue)
+	content := tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(keyField, 1, 1, true).
+		AddItem(tview.NewBox().SetBackgroundColor(tcell.ColorDarkBlue), 1, 1, false).
+		AddItem(result, 0, 1, false).
+		AddItem(help, 1, 1, false)
+	content.SetBorderPadding(0, 0, 1, 1).
+		SetBackgroundColor(tcell.ColorDarkBlue)
+
+	l.app.ShowModal(content, 86, 27, tcell.ColorDarkBlue, func(event *tcell.EventKey) *tcell.EventKey {
+		if event.Key() == tcell.KeyEsc && !keyField.IsOpen() {
+			l.app.DismissModal(l.table)
+			return nil
+		}
+		return event
+	})
+	l.app.SetFocus(keyField)
+	keyField.SetCurrentOption(0)
+}
+
+func formatStats(st aggregate.NumericStats) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "[yellow::b]Count[-::-]   %d", st.Count)
+	if st.Invalid > 0 {
+		fmt.Fprintf(&sb, " [gray](%d missing or not a number)[-]", st.Invalid)
+	}
+	sb.WriteString("\n")
+	if st.Count == 0 {
+		return sb.String()
+	}
+	fmt.Fprintf(&sb, "[yellow::b]Min[-::-]     %-14s [yellow::b]Max[-::-]  %-14s [yellow::b]Mean[-::-] %s\n",
+		formatNumber(st.Min), formatNumber(st.Max), formatNumber(st.Mean))
+	fmt.Fprintf(&sb, "[yellow::b]p50[-::-]     %-14s [yellow::b]p90[-::-]  %-14s [yellow::b]p99[-::-]  %s\n\n",
+		formatNumber(st.P50), formatNumber(st.P90), formatNumber(st.P99))
+
+	peak, width := 0, 0
+	for _, b := range st.Distribution {
+		peak = max(peak, b.Count)
+		width = max(width, len(formatNumber(b.From)), len(formatNumber(b.To)))
+	}
+	for _, b := range st.Distribution {
+		n := (b.Count*statsBarWidth + peak - 1) / peak
+		fmt.Fprintf(&sb, "%*s – %-*s │[green]%s[-]%s %d\n",
+			width, formatNumber(b.From), width, formatNumber(b.To),
+			strings.Repeat("█", n), strings.Repeat(" ", statsBarWidth-n), b.Count)
+	}
+	return sb.String()
+}
+
+func formatNumber(n float64) string {
+	return strconv.FormatFloat(math.Round(n*1000)/1000, 'f', -1, 64)
+}
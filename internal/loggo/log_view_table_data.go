@@ -27,6 +27,7 @@ import (
 	"regexp"
 	"strings"
 
+	"github.com/aurc/loggo/internal/char"
 	"github.com/aurc/loggo/internal/config"
 	"github.com/gdamore/tcell/v2"
 	"github.com/rivo/tview"
@@ -40,9 +41,41 @@ type LogData struct {
 func (d *LogData) GetCell(row, column int) *tview.TableCell {
 	d.logView.filterLock.RLock()
 	defer d.logView.filterLock.RUnlock()
-	if row == -1 || len(d.logView.finSlice) < row-1 || column == -1 {
+	if row == -1 || len(d.logView.rows()) < row-1 || column == -1 {
 		return nil
 	}
+	if row > 0 && d.logView.isDivider(row-1) {
+		tc := tview.NewTableCell(strings.Repeat(char.SymDivider, 3)).
+			SetTextColor(tcell.ColorGray).
+			SetBackgroundColor(tcell.ColorBlack).
+			SetSelectable(false)
+		if column == 0 {
+			tc.SetAlign(tview.AlignRight)
+		}
+		return tc
+	}
+	var entry map[string]any
+	matched, context := false, false
+	lineNumber := ""
+	if row > 0 {
+		var ok bool
+		if entry, ok = d.logView.entryAt(row - 1); !ok {
+			return nil
+		}
+		matched = d.logView.isMatch(row - 1)
+		context = d.logView.isContext(row - 1)
+		// the position in the stream, regardless of the filter
+		lineNumber = fmt.Sprintf("%d ", d.logView.rows()[row-1]+1)
+		if d.logView.showFilteredIndex {
+			lineNumber = fmt.Sprintf("[gray]%d[-] %s", row, lineNumber)
+		}
+	}
+	rowBackground := func(c tcell.Color) tcell.Color {
+		if matched {
+			return d.logView.config.HighlightBackground()
+		}
+		return c
+	}
 	if column == 0 {
 		if row == 0 {
 			tc := tview.NewTableCell("[yellow] Line # ").
@@ -51,17 +84,17 @@ func (d *LogData) GetCell(row, column int) *tview.TableCell {
 				SetSelectable(false)
 			return tc
 		} else {
-			if _, ok := d.logView.finSlice[row-1][config.ParseErr]; ok {
-				tc := tview.NewTableCell(fmt.Sprintf("%d ", row)).
+			if _, ok := entry[config.ParseErr]; ok {
+				tc := tview.NewTableCell(lineNumber).
 					SetTextColor(tcell.ColorRed).
 					SetAlign(tview.AlignRight).
-					SetBackgroundColor(tcell.ColorBlack)
+					SetBackgroundColor(rowBackground(tcell.ColorBlack))
 				return tc
 			} else {
-				tc := tview.NewTableCell(fmt.Sprintf("%d ", row)).
+				tc := tview.NewTableCell(lineNumber).
 					SetTextColor(tcell.ColorYellow).
 					SetAlign(tview.AlignRight).
-					SetBackgroundColor(tcell.ColorBlack)
+					SetBackgroundColor(rowBackground(tcell.ColorBlack))
 				return tc
 			}
 		}
@@ -78,6 +111,9 @@ func (d *LogData) GetCell(row, column int) *tview.TableCell {
 	}
 	// Set Headers
 	if row == 0 {
+		if ind := d.logView.sortIndicator(k.Name); len(ind) > 0 {
+			tc.SetText(" " + k.Name + " " + ind + " ")
+		}
 		tc.SetTextColor(tcell.ColorYellow).
 			SetAlign(tview.AlignCenter).
 			SetBackgroundColor(tcell.ColorBlack).
@@ -85,7 +121,7 @@ func (d *LogData) GetCell(row, column int) *tview.TableCell {
 		return tc
 	}
 	// Set Body Cells
-	cellValue := k.ExtractValue(d.logView.finSlice[row-1])
+	cellValue := k.ExtractValue(entry)
 	var bgColor, fgColor tcell.Color
 	if len(k.Color.Foreground) == 0 {
 		fgColor = k.Type.GetColor()
@@ -113,21 +149,24 @@ func (d *LogData) GetCell(row, column int) *tview.TableCell {
 	}
 
 	if k.Name == config.TextPayload {
-		if _, ok := d.logView.finSlice[row-1][config.ParseErr]; ok {
+		if _, ok := entry[config.ParseErr]; ok {
 			fgColor = tcell.ColorBlue
 		}
 	}
+	if context {
+		fgColor = tcell.ColorGray
+	}
 
 	return tc.
-		SetBackgroundColor(bgColor).
+		SetBackgroundColor(rowBackground(bgColor)).
 		SetTextColor(fgColor).
-		SetText(fmt.Sprintf("%s", cellValue))
+		SetText(strings.ReplaceAll(cellValue, "\n", " "+char.SymNewLine+" "))
 }
 
 func (d *LogData) GetRowCount() int {
 	d.logView.filterLock.RLock()
 	defer d.logView.filterLock.RUnlock()
-	return len(d.logView.finSlice) + 1
+	return len(d.logView.rows()) + 1
 }
 
 func (d *LogData) GetColumnCount() int {
@@ -0,0 +1,258 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package loggo
+
+import (
+	"fmt"
+	"iter"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/aurc/loggo/internal/aggregate"
+	"github.com/aurc/loggo/internal/config"
+	"github.com/aurc/loggo/internal/filter"
+	"github.com/gdamore/tcell/v2"
+	"github.com/rivo/tview"
+)
+
+const (
+	topValuesLimit   = 50
+	topValuesBuckets = 20
+)
+
+func (l *LogView) showTopValues() {
+	keyField := tview.NewInputField().
+		SetLabel("Key ").
+		SetText(l.defaultTopValuesKey())
+	keyField.SetAutocompleteFunc(func(text string) []string {
+		return l.keySuggestions(text)
+	})
+	table := tview.NewTable().
+		SetSelectable(true, false).
+		SetFixed(1, 0)
+	table.SetBackgroundColor(tcell.ColorDarkBlue)
+	help := tview.NewTextView().
+		SetDynamicColors(true).
+		SetText(`[yellow::b]Enter[-::-] Filter by value  [yellow::b]Tab[-::-] Change key  [yellow::b]Esc[-::-] Close`)
+	help.SetBackgroundColor(tcell.ColorDarkBlue)
+	content := tview.NewFlex().SetDirection(tview.FlexRow).
+		AddItem(keyField, 1, 1, true).
+		AddItem(tview.NewBox().SetBackgroundColor(tcell.ColorDarkBlue), 1, 1, false).
+		AddItem(table, 0, 1, false).
+		AddItem(help, 1, 1, false)
+	content.SetBorderPadding(0, 0, 1, 1).
+		SetBackgroundColor(tcell.ColorDarkBlue)
+
+	load := func() {
+		key := keyField.GetText()
+		table.Clear()
+		table.SetCell(0, 0, tview.NewTableCell("Computing...").SetSelectable(false))
+		go func() {
+			top := l.topValues(key)
+			l.app.QueueUpdateDraw(func() {
+				l.fillTopValues(table, top)
+				table.SetSelectedFunc(func(row, _ int) {
+					if expr, ok := topValueExpression(l.keyConfig(key), top, row-1); ok {
+						l.app.DismissModal(l.table)
+						l.filterBy(expr)
+					}
+				})
+				table.Select(1, 0)
+				l.app.SetFocus(table)
+			})
+		}()
+	}
+	keyField.SetDoneFunc(func(key tcell.Key) {
+		if key == tcell.KeyEnter {
+			load()
+		}
+	})
+	keyField.SetAutocompletedFunc(func(text string, _ int, source int) bool {
+		keyField.SetText(text)
+		if source == tview.AutocompletedEnter {
+			load()
+		}
+		return true
+	})
+
+	l.app.ShowModal(content, 100, 24, tcell.ColorDarkBlue, func(event *tcell.EventKey) *tcell.EventKey {
+		switch event.Key() {
+		case tcell.KeyEsc:
+			l.app.DismissModal(l.table)
+			return nil
+		case tcell.KeyTab:
+			if table.HasFocus() {
+				l.app.SetFocus(keyField)
+				return nil
+			}
+		}
+		return event
+	})
+	l.app.SetFocus(keyField)
+	load()
+}
+
+// topValues counts the values of key across the filtered view.
+func (l *LogView) topValues(key string) aggregate.TopValues {
+	return aggregate.Top(*l.keyConfig(key), l.timeKey(), l.filteredEntries(), topValuesLimit, topValuesBuckets)
+}
+
+// filteredEntries snapshots the filtered view, yielding its entries in
+// arrival order.
+func (l *LogView) filteredEntries() iter.Seq[map[string]any] {
+	l.filterLock.RLock()
+	seqs := slices.Clone(l.rows())
+	l.filterLock.RUnlock()
+	slices.Sort(seqs)
+	return func(yield func(map[string]any) bool) {
+		for _, seq := range seqs {
+			if m, ok := l.inSlice.Get(seq); ok && !yield(m) {
+				return
+			}
+		}
+	}
+}
+
+func (l *LogView) fillTopValues(table *tview.Table, top aggregate.TopValues) {
+	table.Clear()
+	for i, h := range []string{"Value", "Count", "%", "Over Time"} {
+		table.SetCell(0, i, tview.NewTableCell(h).
+			SetTextColor(tcell.ColorYellow).
+			SetSelectable(false))
+	}
+	row := 1
+	add := func(label string, c aggregate.Count, fg tcell.Color) {
+		table.SetCell(row, 0, tview.NewTableCell(tview.Escape(label)).
+			SetTextColor(fg).
+			SetMaxWidth(50).
+			SetExpansion(1))
+		table.SetCell(row, 1, tview.NewTableCell(strconv.Itoa(c.Count)).
+			SetAlign(tview.AlignRight))
+		table.SetCell(row, 2, tview.NewTableCell(fmt.Sprintf("%.1f%%", c.Percent)).
+			SetAlign(tview.AlignRight))
+		table.SetCell(row, 3, tview.NewTableCell(aggregate.Sparkline(c.Series)).
+			SetTextColor(tcell.ColorGreen))
+		row++
+	}
+	for _, c := range top.Values {
+		add(c.Value, c, tcell.ColorWhite)
+	}
+	if top.Others.Count > 0 {
+		add("(others)", top.Others, tcell.ColorGray)
+	}
+	if top.Missing.Count > 0 {
+		add("(missing)", top.Missing, tcell.ColorGray)
+	}
+	if row == 1 {
+		table.SetCell(row, 0, tview.NewTableCell("No entries").SetSelectable(false))
+	}
+}
+
+// topValueExpression is the filter expression selecting the entries of the
+// value at the given index of the summary.
+func topValueExpression(key *config.Key, top aggregate.TopValues, index int) (string, bool) {
+	switch {
+	case index >= 0 && index < len(top.Values):
+		value := top.Values[index].Value
+		if key.Type != config.TypeNumber {
+			value = filter.QuoteValue(value)
+		}
+		return fmt.Sprintf("%s = %s", key.Name, value), true
+	case index == len(top.Values) && top.Others.Count > 0:
+		// others can't be expressed as a single value
+		return "", false
+	case top.Missing.Count > 0:
+		return fmt.Sprintf("MISSING %s", key.Name), true
+	}
+	return "", false
+}
+
+// filterBy shows the filter bar and narrows the applied filter, if any, down
+// with the expression.
+func (l *LogView) filterBy(expression string) {
+	if l.hideFilter {
+		l.hideFilter = false
+		l.makeLayouts()
+	}
+	if applied := l.filterView.Applied(); len(applied) > 0 {
+		expression = fmt.Sprintf("(%s) AND %s", applied, expression)
+	}
+	l.filterView.SetExpression(expression)
+}
+
+// keyConfig returns the template key with the given name, or a string key
+// when it isn't part of the template.
+func (l *LogView) keyConfig(name string) *config.Key {
+	for i := range l.config.Keys {
+		if l.config.Keys[i].Name == name {
+			return &l.config.Keys[i]
+		}
+	}
+	return &config.Key{Name: name, Type: config.TypeString}
+}
+
+// timeKey is the first datetime key of the template, if any.
+func (l *LogView) timeKey() *config.Key {
+	for i := range l.config.Keys {
+		if l.config.Keys[i].Type == config.TypeDateTime {
+			return &l.config.Keys[i]
+		}
+	}
+	return nil
+}
+
+// defaultTopValuesKey picks a key likely worth summarising: the first one with
+// colours by value, e.g. a severity, or else the first key of the template.
+func (l *LogView) defaultTopValuesKey() string {
+	if k := l.severityKey(); k != nil {
+		return k.Name
+	}
+	if len(l.config.Keys) > 0 {
+		return l.config.Keys[0].Name
+	}
+	return ""
+}
+
+// keySuggestions lists the template and observed keys starting with text.
+func (l *LogView) keySuggestions(text string) []string {
+	if len(text) == 0 {
+		return nil
+	}
+	var names []string
+	for _, k := range l.config.Keys {
+		names = append(names, k.Name)
+	}
+	for _, k := range l.observedKeys() {
+		if !slices.Contains(names, k) {
+			names = append(names, k)
+		}
+	}
+	var found []string
+	for _, n := range names {
+		if strings.HasPrefix(n, text) {
+			found = append(found, n)
+		}
+	}
+	return found
+}
@@ -0,0 +1,210 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package reader
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/aurc/loggo/internal/docker"
+)
+
+// Keys injected into every entry streamed from Docker.
+const (
+	DockerContainer = "container"
+	DockerStream    = "stream"
+)
+
+// composeService is the label docker compose sets with the service name.
+const composeService = "com.docker.compose.service"
+
+// DockerOptions selects the containers streamed by MakeDockerReader.
+type DockerOptions struct {
+	// Containers are names, (prefixes of) IDs or compose service names; all
+	// running containers if empty.
+	Containers []string
+	// Labels only streams the containers carrying every one of these labels,
+	// "key" or "key=value", e.g. "com.docker.compose.project=shop".
+	Labels []string
+	// Since only streams entries newer than this, the whole log if zero.
+	Since time.Duration
+}
+
+type dockerStream struct {
+	reader
+	*followers
+	client  *docker.Client
+	options DockerOptions
+}
+
+// MakeDockerReader builds a streamer following the logs of the containers
+// matching options through the Docker Engine API, including containers
+// started (or restarted) later. Each entry is tagged with its container name
+// and stream (stdout or stderr).
+func MakeDockerReader(client *docker.Client, options DockerOptions, strChan chan string) Reader {
+	if strChan == nil {
+		strChan = make(chan string, 1)
+	}
+	return &dockerStream{
+		reader: reader{
+			strChan:    strChan,
+			readerType: TypeDocker,
+		},
+		followers: newFollowers(strChan),
+		client:    client,
+		options:   options,
+	}
+}
+
+func (s *dockerStream) StreamInto() error {
+	// every stream gets its own assembler, rules are checked up front
+	if _, err := newAssembler(s.multiline, nil); err != nil {
+		return err
+	}
+	// listen to starts before listing, so no container falls in between
+	events := make(chan docker.Event)
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		if err := s.client.Starts(s.ctx, s.options.Labels, events); err != nil && s.ctx.Err() == nil && s.onError != nil {
+			s.onError(err)
+		}
+	}()
+	containers, err := s.client.Containers(s.ctx, s.options.Labels)
+	if err != nil {
+		s.cancel()
+		s.wg.Wait()
+		return err
+	}
+	var since time.Time
+	if s.options.Since > 0 {
+		since = time.Now().Add(-s.options.Since)
+	}
+	for _, c := range containers {
+		s.follow(c, since)
+	}
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		for {
+			select {
+			case <-s.ctx.Done():
+				return
+			case ev := <-events:
+				c, err := s.client.Inspect(s.ctx, ev.ID)
+				if err != nil {
+					continue
+				}
+				// only the output of the new run is streamed
+				s.follow(c, ev.Time)
+			}
+		}
+	}()
+	return nil
+}
+
+func (s *dockerStream) matches(c docker.Container) bool {
+	if len(s.options.Containers) == 0 {
+		return true
+	}
+	for _, want := range s.options.Containers {
+		if c.Name == want || strings.HasPrefix(c.ID, want) || c.Labels[composeService] == want {
+			return true
+		}
+	}
+	return false
+}
+
+// follow streams the output of a container run not streamed yet.
+func (s *dockerStream) follow(c docker.Container, since time.Time) {
+	if !s.matches(c) {
+		return
+	}
+	// a restarted container keeps its id, each of its runs is streamed afresh
+	run := c.ID + "@" + c.StartedAt.String()
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.following[run] {
+		return
+	}
+	s.following[run] = true
+	stdout := s.lines(c.Name, "stdout")
+	stderr := s.lines(c.Name, "stderr")
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		defer func() {
+			stdout.Close()
+			stderr.Close()
+			s.lock.Lock()
+			delete(s.following, run)
+			s.lock.Unlock()
+		}()
+		logs, err := s.client.Logs(s.ctx, c.ID, since)
+		if err != nil {
+			if s.ctx.Err() == nil && s.onError != nil {
+				s.onError(err)
+			}
+			return
+		}
+		defer logs.Close()
+		if c.TTY {
+			_, _ = io.Copy(stdout, logs)
+		} else {
+			_ = docker.Demux(logs, stdout, stderr)
+		}
+	}()
+}
+
+// lines returns a writer whose lines are assembled into entries tagged with
+// the container and stream. The caller must hold the lock.
+func (s *dockerStream) lines(container, stream string) io.WriteCloser {
+	tags := map[string]string{
+		DockerContainer: container,
+		DockerStream:    stream,
+	}
+	a := s.assembler(s.multiline, tags)
+	r, w := io.Pipe()
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		scanner := bufio.NewScanner(r)
+		scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+		for scanner.Scan() {
+			a.Add(scanner.Text())
+		}
+		a.Flush()
+		s.forget(a)
+		// keep draining so the writer never blocks on a line too long
+		_, _ = io.Copy(io.Discard, r)
+	}()
+	return w
+}
+
+func (s *dockerStream) Close() {
+	s.followers.close()
+}
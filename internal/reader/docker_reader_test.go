@@ -0,0 +1,244 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package reader
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aurc/loggo/internal/config"
+	"github.com/aurc/loggo/internal/docker"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeEngine serves the parts of the Docker Engine API the docker reader uses.
+type fakeEngine struct {
+	containers map[string]fakeContainer
+	starts     chan string
+	lock       sync.Mutex
+}
+
+type fakeContainer struct {
+	name      string
+	service   string
+	tty       bool
+	logs      []byte
+	startedAt time.Time
+	// running keeps the logs open until the request ends
+	running bool
+}
+
+func (e *fakeEngine) container(id string) fakeContainer {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	return e.containers[id]
+}
+
+func muxFrame(stream byte, payload string) []byte {
+	header := make([]byte, 8)
+	header[0] = stream
+	binary.BigEndian.PutUint32(header[4:], uint32(len(payload)))
+	return append(header, payload...)
+}
+
+func (e *fakeEngine) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	switch {
+	case r.URL.Path == "/containers/json":
+		var list []map[string]any
+		e.lock.Lock()
+		defer e.lock.Unlock()
+		for id, c := range e.containers {
+			if c.name != "shop-web-2" {
+				list = append(list, map[string]any{"Id": id, "Names": []string{"/" + c.name}})
+			}
+		}
+		_ = json.NewEncoder(w).Encode(list)
+	case r.URL.Path == "/events":
+		w.(http.Flusher).Flush()
+		for {
+			select {
+			case id := <-e.starts:
+				_, _ = fmt.Fprintf(w, `{"id":%q,"timeNano":%d}`+"\n", id, time.Now().UnixNano())
+				w.(http.Flusher).Flush()
+			case <-r.Context().Done():
+				return
+			}
+		}
+	case len(parts) == 3 && parts[2] == "json":
+		c := e.container(parts[1])
+		_ = json.NewEncoder(w).Encode(map[string]any{
+			"Id":   parts[1],
+			"Name": "/" + c.name,
+			"Config": map[string]any{
+				"Tty":    c.tty,
+				"Labels": map[string]string{"com.docker.compose.service": c.service},
+			},
+			"State": map[string]any{"StartedAt": c.startedAt},
+		})
+	case len(parts) == 3 && parts[2] == "logs":
+		c := e.container(parts[1])
+		if c.logs == nil {
+			http.Error(w, `{"message":"no logs"}`, http.StatusInternalServerError)
+			return
+		}
+		_, _ = w.Write(c.logs)
+		if c.running {
+			w.(http.Flusher).Flush()
+			<-r.Context().Done()
+		}
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func TestDockerStream_StreamInto(t *testing.T) {
+	engine := &fakeEngine{
+		containers: map[string]fakeContainer{
+			"aaa111": {name: "shop-web-1", service: "web", logs: append(
+				muxFrame(1, `{"msg":"web 1 out"}`+"\n"),
+				muxFrame(2, "web 1 err\n")...)},
+			"bbb222": {name: "shop-db-1", service: "db", logs: muxFrame(1, "db out\n")},
+			"ccc333": {name: "shop-web-2", service: "web", tty: true, logs: []byte("web 2 tty\n")},
+		},
+		starts: make(chan string, 1),
+	}
+	server := httptest.NewServer(engine)
+	defer server.Close()
+	client, err := docker.NewClient(server.URL)
+	assert.NoError(t, err)
+
+	r := MakeDockerReader(client, DockerOptions{Containers: []string{"web"}}, nil)
+	assert.NoError(t, r.StreamInto())
+	defer r.Close()
+	engine.starts <- "ccc333"
+
+	var entries []map[string]any
+	timeout := time.After(5 * time.Second)
+	for len(entries) < 3 {
+		select {
+		case line := <-r.ChanReader():
+			m := make(map[string]any)
+			assert.NoError(t, json.Unmarshal([]byte(line), &m))
+			if msg, ok := m["msg"]; ok {
+				m[config.TextPayload] = msg
+				delete(m, "msg")
+			}
+			delete(m, config.ParseErr)
+			entries = append(entries, m)
+		case <-timeout:
+			t.Fatalf("timed out, got %v", entries)
+		}
+	}
+	assert.ElementsMatch(t, []map[string]any{
+		{config.TextPayload: "web 1 out", DockerContainer: "shop-web-1", DockerStream: "stdout"},
+		{config.TextPayload: "web 1 err", DockerContainer: "shop-web-1", DockerStream: "stderr"},
+		{config.TextPayload: "web 2 tty", DockerContainer: "shop-web-2", DockerStream: "stdout"},
+	}, entries)
+	// the logs ended, nothing is kept for their streams
+	s := r.(*dockerStream)
+	assert.Eventually(t, func() bool {
+		s.lock.Lock()
+		defer s.lock.Unlock()
+		return len(s.assemblers) == 0
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestDockerStream_Restart(t *testing.T) {
+	started := time.Now().Add(-time.Minute)
+	engine := &fakeEngine{
+		containers: map[string]fakeContainer{
+			"aaa111": {name: "shop-web-1", tty: true, logs: []byte("run 1\n"), startedAt: started, running: true},
+		},
+		starts: make(chan string, 1),
+	}
+	server := httptest.NewServer(engine)
+	defer server.Close()
+	client, err := docker.NewClient(server.URL)
+	assert.NoError(t, err)
+
+	r := MakeDockerReader(client, DockerOptions{}, nil)
+	assert.NoError(t, r.StreamInto())
+	defer r.Close()
+	next := func() string {
+		select {
+		case line := <-r.ChanReader():
+			m := make(map[string]any)
+			assert.NoError(t, json.Unmarshal([]byte(line), &m))
+			return fmt.Sprint(m[config.TextPayload])
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out")
+		}
+		return ""
+	}
+	assert.Equal(t, "run 1", next())
+
+	// the same container starts again while its first run is still followed
+	engine.lock.Lock()
+	engine.containers["aaa111"] = fakeContainer{
+		name: "shop-web-1", tty: true, logs: []byte("run 2\n"), startedAt: started.Add(time.Second), running: true,
+	}
+	engine.lock.Unlock()
+	engine.starts <- "aaa111"
+	assert.Equal(t, "run 2", next())
+}
+
+func TestDockerStream_LogsError(t *testing.T) {
+	engine := &fakeEngine{
+		containers: map[string]fakeContainer{
+			"aaa111": {name: "shop-web-1"},
+		},
+		starts: make(chan string, 1),
+	}
+	server := httptest.NewServer(engine)
+	defer server.Close()
+	client, err := docker.NewClient(server.URL)
+	assert.NoError(t, err)
+
+	r := MakeDockerReader(client, DockerOptions{}, nil)
+	errs := make(chan error, 1)
+	r.ErrorNotifier(func(err error) {
+		errs <- err
+	})
+	assert.NoError(t, r.StreamInto())
+	defer r.Close()
+	select {
+	case err := <-errs:
+		assert.ErrorContains(t, err, "no logs")
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out")
+	}
+}
+
+func TestDockerStream_NoEngine(t *testing.T) {
+	client, err := docker.NewClient("unix://" + t.TempDir() + "/docker.sock")
+	assert.NoError(t, err)
+	assert.Error(t, MakeDockerReader(client, DockerOptions{}, nil).StreamInto())
+}
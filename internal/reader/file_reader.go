@@ -30,12 +30,23 @@ import (
 
 type fileStream struct {
 	reader
-	fileName string
-	tail     *tail.Tail
+	fileName  string
+	tail      *tail.Tail
+	assembler *assembler
+	done      chan struct{}
 }
 
 func (s *fileStream) StreamInto() error {
 	var err error
+	s.assembler, err = newAssembler(s.multiline, func(record string) {
+		select {
+		case s.strChan <- record:
+		case <-s.done:
+		}
+	})
+	if err != nil {
+		return err
+	}
 	s.tail, err = tail.TailFile(s.fileName, tail.Config{Follow: true, Poll: true})
 	if err != nil {
 		return err
@@ -43,7 +54,7 @@ func (s *fileStream) StreamInto() error {
 
 	go func() {
 		for line := range s.tail.Lines {
-			s.strChan <- line.Text
+			s.assembler.Add(line.Text)
 		}
 	}()
 	return nil
@@ -51,5 +62,7 @@ func (s *fileStream) StreamInto() error {
 
 func (s *fileStream) Close() {
 	s.tail.Kill(fmt.Errorf("stopped by Close method"))
+	close(s.done)
+	s.assembler.Stop()
 	close(s.strChan)
 }
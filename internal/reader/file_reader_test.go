@@ -79,4 +79,28 @@ func TestFileStream_StreamInto(t *testing.T) {
 		diff := (now - before) / int64(1000)
 		assert.True(t, diff >= int64(1))
 	})
+	t.Run("Close while nobody drains the channel", func(t *testing.T) {
+		filePath := path.Join(t.TempDir(), "undrained.txt")
+		var content string
+		for i := 0; i < 100; i++ {
+			content += fmt.Sprintf("line %d\n", i+1)
+		}
+		assert.NoError(t, os.WriteFile(filePath, []byte(content), 0644))
+
+		streamReceiver := make(chan string)
+		reader := MakeReader(filePath, streamReceiver)
+		assert.NoError(t, reader.StreamInto())
+		<-streamReceiver
+
+		closed := make(chan struct{})
+		go func() {
+			reader.Close()
+			close(closed)
+		}()
+		select {
+		case <-closed:
+		case <-time.After(5 * time.Second):
+			t.Fatal("Close blocked on the undrained channel")
+		}
+	})
 }
@@ -0,0 +1,84 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package reader
+
+import (
+	"context"
+	"sync"
+)
+
+// followers keeps track of the sources a reader follows concurrently (e.g. the
+// containers of the k8s and docker readers), each with its own assembler, so
+// the reader can stop all of them when closed.
+type followers struct {
+	out        chan string
+	ctx        context.Context
+	cancel     context.CancelFunc
+	following  map[string]bool
+	assemblers map[*assembler]bool
+	lock       sync.Mutex
+	wg         sync.WaitGroup
+}
+
+func newFollowers(out chan string) *followers {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &followers{
+		out:        out,
+		ctx:        ctx,
+		cancel:     cancel,
+		following:  make(map[string]bool),
+		assemblers: make(map[*assembler]bool),
+	}
+}
+
+// assembler returns a new assembler streaming its records tagged with tags.
+// The rules must have been checked already. The caller must hold the lock.
+func (f *followers) assembler(rules *MultilineRules, tags map[string]string) *assembler {
+	a, _ := newAssembler(rules, func(record string) {
+		select {
+		case f.out <- tagLineWith(record, tags):
+		case <-f.ctx.Done():
+		}
+	})
+	f.assemblers[a] = true
+	return a
+}
+
+// forget drops the assembler of a source no longer followed.
+func (f *followers) forget(a *assembler) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	delete(f.assemblers, a)
+}
+
+// close stops every source, waits for them and closes the channel.
+func (f *followers) close() {
+	f.cancel()
+	f.lock.Lock()
+	for a := range f.assemblers {
+		a.Stop()
+	}
+	f.lock.Unlock()
+	f.wg.Wait()
+	close(f.out)
+}
@@ -0,0 +1,51 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package reader
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFollowers(t *testing.T) {
+	tags := map[string]string{K8sPod: "api-1"}
+	f := newFollowers(make(chan string, 1))
+
+	f.lock.Lock()
+	a := f.assembler(nil, tags)
+	f.lock.Unlock()
+	a.Add("hello")
+	assert.Equal(t, tagLineWith("hello", tags), <-f.out)
+	f.forget(a)
+	assert.Empty(t, f.assemblers)
+
+	// a pending record is dropped once closed
+	f.lock.Lock()
+	b := f.assembler(&MultilineRules{Indented: true}, tags)
+	f.lock.Unlock()
+	b.Add("pending")
+	f.close()
+	_, open := <-f.out
+	assert.False(t, open)
+}
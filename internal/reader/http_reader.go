@@ -0,0 +1,362 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package reader
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aurc/loggo/internal/config"
+	"github.com/aurc/loggo/internal/decoder"
+)
+
+// maxIngestBody caps the size of a single pushed batch.
+const maxIngestBody = 32 << 20
+
+// Keys added to the entries pushed to the ingest endpoints.
+const (
+	IngestTimestamp = "timestamp"
+	IngestIndex     = "_index"
+)
+
+type httpStream struct {
+	reader
+	listen   string
+	listener net.Listener
+	server   *http.Server
+	lock     sync.RWMutex
+	closed   bool
+	done     chan struct{}
+}
+
+// MakeHTTPReader builds an http server listening on listen (e.g. ":9999")
+// that streams the log batches pushed to it:
+//
+//   - POST /ingest (or /): newline delimited json, one entry per line. Lines
+//     that aren't json are decoded by the log view as any other line.
+//   - POST /loki/api/v1/push: Loki's json push format. Stream labels become
+//     keys of each entry.
+//   - POST /_bulk or /{index}/_bulk: Elasticsearch's bulk format, the index
+//     is kept under _index.
+//
+// Bodies may be gzip encoded.
+func MakeHTTPReader(listen string, strChan chan string) Reader {
+	if strChan == nil {
+		strChan = make(chan string, 1)
+	}
+	s := &httpStream{
+		reader: reader{
+			strChan:    strChan,
+			readerType: TypeHTTP,
+		},
+		listen: listen,
+		done:   make(chan struct{}),
+	}
+	s.server = &http.Server{
+		Handler:           s.handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	return s
+}
+
+func (s *httpStream) StreamInto() error {
+	var err error
+	if s.listener, err = net.Listen("tcp", s.listen); err != nil {
+		return err
+	}
+	go func() {
+		if err := s.server.Serve(s.listener); err != nil && err != http.ErrServerClosed && s.onError != nil {
+			s.onError(err)
+		}
+	}()
+	return nil
+}
+
+// Addr is the address the server listens on.
+func (s *httpStream) Addr() net.Addr {
+	if s.listener == nil {
+		return nil
+	}
+	return s.listener.Addr()
+}
+
+// handler serves the ingest endpoints.
+func (s *httpStream) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /loki/api/v1/push", s.lokiPush)
+	mux.HandleFunc("POST /_bulk", s.bulk)
+	mux.HandleFunc("POST /{index}/_bulk", s.bulk)
+	mux.HandleFunc("POST /ingest", s.ingest)
+	mux.HandleFunc("POST /{$}", s.ingest)
+	// elasticsearch clients check what they're talking to first
+	mux.HandleFunc("GET /{$}", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Elastic-Product", "Elasticsearch")
+		writeJSON(w, http.StatusOK, map[string]any{
+			"name":         "loggo",
+			"cluster_name": "loggo",
+			"version":      map[string]any{"number": "8.0.0"},
+			"tagline":      "You Know, for Search",
+		})
+	})
+	return mux
+}
+
+// requestBody reads the request body, gzip decoded if need be.
+func requestBody(w http.ResponseWriter, r *http.Request) (io.Reader, error) {
+	var rd io.Reader = http.MaxBytesReader(w, r.Body, maxIngestBody)
+	if r.Header.Get("Content-Encoding") == "gzip" {
+		return gzip.NewReader(rd)
+	}
+	return rd, nil
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]any{"error": err.Error()})
+}
+
+// emit streams a line, returning false once the reader is closed or the
+// client is gone.
+func (s *httpStream) emit(r *http.Request, line string) bool {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	if s.closed {
+		return false
+	}
+	select {
+	case s.strChan <- line:
+		return true
+	case <-s.done:
+	case <-r.Context().Done():
+	}
+	return false
+}
+
+func (s *httpStream) emitMap(r *http.Request, m map[string]any) bool {
+	b, err := json.Marshal(m)
+	if err != nil {
+		return true
+	}
+	return s.emit(r, string(b))
+}
+
+func scanLines(rd io.Reader) *bufio.Scanner {
+	scanner := bufio.NewScanner(rd)
+	scanner.Buffer(make([]byte, 64*1024), maxIngestBody)
+	return scanner
+}
+
+func (s *httpStream) ingest(w http.ResponseWriter, r *http.Request) {
+	rd, err := requestBody(w, r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	scanner := scanLines(rd)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) > 0 && !s.emit(r, line) {
+			writeError(w, http.StatusServiceUnavailable, fmt.Errorf("stream closed"))
+			return
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// lokiPush reads {"streams":[{"stream":{labels},"values":[["<unix ns>","line",{metadata}]]}]},
+// the structured metadata being optional. The protobuf format, which promtail
+// and Alloy push, isn't supported.
+func (s *httpStream) lokiPush(w http.ResponseWriter, r *http.Request) {
+	if !strings.HasPrefix(r.Header.Get("Content-Type"), "application/json") {
+		writeError(w, http.StatusUnsupportedMediaType,
+			fmt.Errorf("only the json push format is supported, not %s", r.Header.Get("Content-Type")))
+		return
+	}
+	rd, err := requestBody(w, r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	var push struct {
+		Streams []struct {
+			Stream map[string]string   `json:"stream"`
+			Values [][]json.RawMessage `json:"values"`
+		} `json:"streams"`
+	}
+	if err := json.NewDecoder(rd).Decode(&push); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	// the whole batch is decoded first so a malformed value rejects all of it
+	var entries []map[string]any
+	for _, st := range push.Streams {
+		for _, v := range st.Values {
+			m, err := lokiEntry(v, st.Stream)
+			if err != nil {
+				writeError(w, http.StatusBadRequest, err)
+				return
+			}
+			entries = append(entries, m)
+		}
+	}
+	for _, m := range entries {
+		if !s.emitMap(r, m) {
+			writeError(w, http.StatusServiceUnavailable, fmt.Errorf("stream closed"))
+			return
+		}
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// lokiEntry decodes a ["<unix ns>", "line", {metadata}] value into an entry,
+// adding the structured metadata and then the stream labels as keys unless
+// the line already has them.
+func lokiEntry(value []json.RawMessage, labels map[string]string) (map[string]any, error) {
+	if len(value) != 2 && len(value) != 3 {
+		return nil, fmt.Errorf("values must be [timestamp, line] or [timestamp, line, metadata]")
+	}
+	var ts, line string
+	var metadata map[string]string
+	err := errors.Join(json.Unmarshal(value[0], &ts), json.Unmarshal(value[1], &line))
+	if len(value) == 3 {
+		err = errors.Join(err, json.Unmarshal(value[2], &metadata))
+	}
+	if err != nil {
+		return nil, err
+	}
+	m, ok := decoder.JSON().Decode(line)
+	if !ok {
+		m = map[string]any{config.TextPayload: line}
+	}
+	for _, keys := range []map[string]string{metadata, labels} {
+		for k, v := range keys {
+			if _, ok := m[k]; !ok {
+				m[k] = v
+			}
+		}
+	}
+	if ns, err := strconv.ParseInt(ts, 10, 64); err == nil {
+		if _, ok := m[IngestTimestamp]; !ok {
+			m[IngestTimestamp] = time.Unix(0, ns).Format(time.RFC3339Nano)
+		}
+	}
+	return m, nil
+}
+
+// bulk reads action and document line pairs, answering as Elasticsearch does
+// so log shippers consider the documents indexed.
+func (s *httpStream) bulk(w http.ResponseWriter, r *http.Request) {
+	rd, err := requestBody(w, r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	start := time.Now()
+	var items []map[string]any
+	scanner := scanLines(rd)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if len(line) == 0 {
+			continue
+		}
+		var action map[string]struct {
+			Index string `json:"_index"`
+			ID    string `json:"_id"`
+		}
+		if err := json.Unmarshal([]byte(line), &action); err != nil || len(action) != 1 {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("bad bulk action: %s", line))
+			return
+		}
+		for op, meta := range action {
+			index := meta.Index
+			if len(index) == 0 {
+				index = r.PathValue("index")
+			}
+			items = append(items, map[string]any{op: map[string]any{
+				"_index": index,
+				"_id":    meta.ID,
+				"status": http.StatusCreated,
+				"result": "created",
+			}})
+			if op == "delete" {
+				continue
+			}
+			if !scanner.Scan() {
+				writeError(w, http.StatusBadRequest, fmt.Errorf("missing document of %s", line))
+				return
+			}
+			m, ok := decoder.JSON().Decode(scanner.Text())
+			if !ok {
+				writeError(w, http.StatusBadRequest, fmt.Errorf("bad document: %s", scanner.Text()))
+				return
+			}
+			if doc, ok := m["doc"].(map[string]any); ok && op == "update" {
+				m = doc
+			}
+			if len(index) > 0 {
+				m[IngestIndex] = index
+			}
+			if !s.emitMap(r, m) {
+				writeError(w, http.StatusServiceUnavailable, fmt.Errorf("stream closed"))
+				return
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{
+		"took":   time.Since(start).Milliseconds(),
+		"errors": false,
+		"items":  items,
+	})
+}
+
+func (s *httpStream) Close() {
+	close(s.done)
+	_ = s.server.Close()
+	s.lock.Lock()
+	s.closed = true
+	close(s.strChan)
+	s.lock.Unlock()
+}
@@ -0,0 +1,196 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package reader
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/aurc/loggo/internal/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHTTPStream_Handler(t *testing.T) {
+	tests := []struct {
+		name        string
+		givenPath   string
+		givenType   string
+		givenGzip   bool
+		givenBody   string
+		wantStatus  int
+		wantEntries []map[string]any
+		wantBody    string
+	}{
+		{
+			name:       "Newline delimited json",
+			givenPath:  "/ingest",
+			givenBody:  "{\"msg\":\"first\"}\n\n{\"msg\":\"second\"}\n",
+			wantStatus: http.StatusNoContent,
+			wantEntries: []map[string]any{
+				{"msg": "first"},
+				{"msg": "second"},
+			},
+		},
+		{
+			name:       "Gzip encoded to the root",
+			givenPath:  "/",
+			givenGzip:  true,
+			givenBody:  `{"msg":"zipped"}`,
+			wantStatus: http.StatusNoContent,
+			wantEntries: []map[string]any{
+				{"msg": "zipped"},
+			},
+		},
+		{
+			name:      "Loki push",
+			givenPath: "/loki/api/v1/push",
+			givenType: "application/json",
+			givenBody: `{"streams":[{"stream":{"app":"api","level":"info"},"values":[
+				["1700000000000000000","plain line"],
+				["1700000001000000000","{\"level\":\"error\",\"msg\":\"json line\"}"]]}]}`,
+			wantStatus: http.StatusNoContent,
+			wantEntries: []map[string]any{
+				{"app": "api", "level": "info", config.TextPayload: "plain line",
+					IngestTimestamp: time.Unix(1700000000, 0).Format(time.RFC3339Nano)},
+				{"app": "api", "level": "error", "msg": "json line",
+					IngestTimestamp: time.Unix(1700000001, 0).Format(time.RFC3339Nano)},
+			},
+		},
+		{
+			name:      "Loki push with structured metadata",
+			givenPath: "/loki/api/v1/push",
+			givenType: "application/json",
+			givenBody: `{"streams":[{"stream":{"app":"api"},"values":[
+				["1700000000000000000","traced",{"trace_id":"abc","app":"worker"}]]}]}`,
+			wantStatus: http.StatusNoContent,
+			wantEntries: []map[string]any{
+				{"app": "worker", "trace_id": "abc", config.TextPayload: "traced",
+					IngestTimestamp: time.Unix(1700000000, 0).Format(time.RFC3339Nano)},
+			},
+		},
+		{
+			name:      "Loki push with a malformed value",
+			givenPath: "/loki/api/v1/push",
+			givenType: "application/json",
+			givenBody: `{"streams":[{"stream":{"app":"api"},"values":[
+				["1700000000000000000","fine"],["1700000000000000000"]]}]}`,
+			wantStatus: http.StatusBadRequest,
+		},
+		{
+			name:       "Loki protobuf",
+			givenPath:  "/loki/api/v1/push",
+			givenType:  "application/x-protobuf",
+			givenBody:  "binary",
+			wantStatus: http.StatusUnsupportedMediaType,
+		},
+		{
+			name:      "Elasticsearch bulk",
+			givenPath: "/logs/_bulk",
+			givenBody: `{"index":{}}
+{"msg":"indexed"}
+{"create":{"_index":"other","_id":"1"}}
+{"msg":"created"}
+{"delete":{"_id":"2"}}
+{"update":{"_id":"3"}}
+{"doc":{"msg":"updated"}}
+`,
+			wantStatus: http.StatusOK,
+			wantEntries: []map[string]any{
+				{"msg": "indexed", IngestIndex: "logs"},
+				{"msg": "created", IngestIndex: "other"},
+				{"msg": "updated", IngestIndex: "logs"},
+			},
+			wantBody: `"errors":false`,
+		},
+		{
+			name:       "Bad bulk action",
+			givenPath:  "/_bulk",
+			givenBody:  "not json\n",
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := MakeHTTPReader("127.0.0.1:0", make(chan string, 10)).(*httpStream)
+			server := httptest.NewServer(s.handler())
+			defer server.Close()
+			defer s.Close()
+
+			payload := []byte(test.givenBody)
+			if test.givenGzip {
+				var buf bytes.Buffer
+				zw := gzip.NewWriter(&buf)
+				_, _ = zw.Write(payload)
+				assert.NoError(t, zw.Close())
+				payload = buf.Bytes()
+			}
+			req, err := http.NewRequest(http.MethodPost, server.URL+test.givenPath, bytes.NewReader(payload))
+			assert.NoError(t, err)
+			if len(test.givenType) > 0 {
+				req.Header.Set("Content-Type", test.givenType)
+			}
+			if test.givenGzip {
+				req.Header.Set("Content-Encoding", "gzip")
+			}
+			res, err := http.DefaultClient.Do(req)
+			assert.NoError(t, err)
+			b, _ := io.ReadAll(res.Body)
+			res.Body.Close()
+			assert.Equal(t, test.wantStatus, res.StatusCode, string(b))
+			assert.Contains(t, string(b), test.wantBody)
+
+			var entries []map[string]any
+			for len(s.strChan) > 0 {
+				m := make(map[string]any)
+				assert.NoError(t, json.Unmarshal([]byte(<-s.strChan), &m))
+				entries = append(entries, m)
+			}
+			assert.Equal(t, test.wantEntries, entries)
+		})
+	}
+}
+
+func TestHTTPStream_StreamInto(t *testing.T) {
+	r := MakeHTTPReader("127.0.0.1:0", nil)
+	assert.NoError(t, r.StreamInto())
+	defer r.Close()
+	go func() {
+		res, err := http.Post("http://"+r.(*httpStream).Addr().String()+"/ingest",
+			"application/x-ndjson", bytes.NewBufferString(`{"msg":"hello"}`))
+		if err == nil {
+			res.Body.Close()
+		}
+	}()
+	select {
+	case line := <-r.ChanReader():
+		assert.JSONEq(t, `{"msg":"hello"}`, line)
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out")
+	}
+}
@@ -0,0 +1,236 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package reader
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aurc/loggo/internal/decoder"
+)
+
+// JournalTimeLayout is the layout of the timestamp key of journal entries, as
+// set in the built-in journal template.
+const JournalTimeLayout = "2006-01-02T15:04:05.000000Z07:00"
+
+// JournalOptions selects the entries streamed by MakeJournalReader, mirroring
+// the journalctl flags of the same names.
+type JournalOptions struct {
+	// Units only streams the entries of these systemd units.
+	Units []string
+	// User streams the user's journal (units of the user's service manager).
+	User bool
+	// Since is the start of the stream, e.g. "-1h" or "2024-01-02 10:00".
+	Since string
+	// Lines is how many of the latest entries are streamed first, the
+	// journalctl default if zero.
+	Lines int
+	// Priority only streams entries up to this priority, e.g. "warning".
+	Priority string
+	// Directory reads the journal files of a directory instead of the system
+	// journal, e.g. copied over from another machine.
+	Directory string
+}
+
+type journalStream struct {
+	reader
+	options JournalOptions
+	cmd     *exec.Cmd
+	stderr  bytes.Buffer
+	wg      sync.WaitGroup
+	done    chan struct{}
+}
+
+// journalCommand builds the journalctl process, replaced in tests.
+var journalCommand = func(args ...string) *exec.Cmd {
+	return exec.Command("journalctl", args...)
+}
+
+// MakeJournalReader builds a streamer following the systemd journal through
+// journalctl. Each entry keeps the journal fields and gains the timestamp,
+// severity, unit, host and message keys of the built-in journal template.
+func MakeJournalReader(options JournalOptions, strChan chan string) Reader {
+	if strChan == nil {
+		strChan = make(chan string, 1)
+	}
+	return &journalStream{
+		reader: reader{
+			strChan:    strChan,
+			readerType: TypeJournal,
+		},
+		options: options,
+		done:    make(chan struct{}),
+	}
+}
+
+func (s *journalStream) args() []string {
+	args := []string{"--output=json", "--follow", "--no-pager"}
+	for _, u := range s.options.Units {
+		args = append(args, "--unit="+u)
+	}
+	if s.options.User {
+		args = append(args, "--user")
+	}
+	if len(s.options.Since) > 0 {
+		args = append(args, "--since="+s.options.Since)
+	}
+	if s.options.Lines > 0 {
+		args = append(args, "--lines="+strconv.Itoa(s.options.Lines))
+	}
+	if len(s.options.Priority) > 0 {
+		args = append(args, "--priority="+s.options.Priority)
+	}
+	if len(s.options.Directory) > 0 {
+		args = append(args, "--directory="+s.options.Directory)
+	}
+	return args
+}
+
+func (s *journalStream) StreamInto() error {
+	cmd := journalCommand(s.args()...)
+	cmd.Stderr = &s.stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("unable to run journalctl: %w", err)
+	}
+	s.cmd = cmd
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		readErr := s.stream(stdout)
+		if readErr != nil {
+			// nothing reads its output anymore, journalctl would never end
+			_ = cmd.Process.Kill()
+		}
+		err := cmd.Wait()
+		select {
+		case <-s.done:
+		default:
+			switch {
+			case s.onError == nil:
+			case readErr != nil:
+				s.onError(fmt.Errorf("unable to read journalctl: %w", readErr))
+			case err != nil:
+				s.onError(fmt.Errorf("journalctl: %v %s", err, strings.TrimSpace(s.stderr.String())))
+			}
+		}
+	}()
+	return nil
+}
+
+// stream feeds the entries read from r until it ends, returning why reading
+// failed if it didn't end cleanly.
+func (s *journalStream) stream(r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	// journal entries may carry large fields, e.g. core dump back traces
+	scanner.Buffer(make([]byte, 64*1024), 8*1024*1024)
+	for scanner.Scan() {
+		if line := JournalEntry(scanner.Text()); len(line) > 0 {
+			select {
+			case s.strChan <- line:
+			case <-s.done:
+				return nil
+			}
+		}
+	}
+	return scanner.Err()
+}
+
+// JournalEntry maps a journalctl json entry onto the keys of the built-in
+// journal template: timestamp (from __REALTIME_TIMESTAMP), severity (from
+// PRIORITY), unit (from _SYSTEMD_UNIT, or the syslog identifier), host and
+// message. Lines that aren't journal entries are returned as they are.
+func JournalEntry(line string) string {
+	m := make(map[string]any)
+	if err := json.Unmarshal([]byte(line), &m); err != nil {
+		return line
+	}
+	if us, err := strconv.ParseInt(journalField(m, "__REALTIME_TIMESTAMP"), 10, 64); err == nil {
+		m["timestamp"] = time.UnixMicro(us).Format(JournalTimeLayout)
+	}
+	if p, err := strconv.Atoi(journalField(m, "PRIORITY")); err == nil {
+		if severity, ok := decoder.SeverityName(p); ok {
+			m["severity"] = severity
+		}
+	}
+	for _, k := range []string{"_SYSTEMD_UNIT", "_SYSTEMD_USER_UNIT", "SYSLOG_IDENTIFIER", "_COMM"} {
+		if v := journalField(m, k); len(v) > 0 {
+			m["unit"] = v
+			break
+		}
+	}
+	if v := journalField(m, "_HOSTNAME"); len(v) > 0 {
+		m["host"] = v
+	}
+	m["message"] = journalField(m, "MESSAGE")
+	b, err := json.Marshal(m)
+	if err != nil {
+		return line
+	}
+	return string(b)
+}
+
+// journalField reads a journal field as text. Fields that aren't valid UTF-8
+// come as an array of bytes, and fields set several times as an array of
+// values, of which the first is used.
+func journalField(m map[string]any, key string) string {
+	switch v := m[key].(type) {
+	case string:
+		return v
+	case []any:
+		if len(v) == 0 {
+			return ""
+		}
+		if _, ok := v[0].(float64); !ok {
+			return journalField(map[string]any{key: v[0]}, key)
+		}
+		b := make([]byte, 0, len(v))
+		for _, n := range v {
+			if f, ok := n.(float64); ok {
+				b = append(b, byte(f))
+			}
+		}
+		return string(b)
+	}
+	return ""
+}
+
+func (s *journalStream) Close() {
+	close(s.done)
+	if s.cmd != nil {
+		_ = s.cmd.Process.Kill()
+	}
+	s.wg.Wait()
+	close(s.strChan)
+}
@@ -0,0 +1,155 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package reader
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestJournalEntry(t *testing.T) {
+	ts := time.Date(2024, 1, 2, 10, 0, 0, 123456000, time.Local)
+	realtime := `"__REALTIME_TIMESTAMP":"` + strconv.FormatInt(ts.UnixMicro(), 10) + `"`
+	tests := []struct {
+		name      string
+		givenLine string
+		wantKeys  map[string]any
+	}{
+		{
+			name:      "System unit",
+			givenLine: `{` + realtime + `,"PRIORITY":"3","_SYSTEMD_UNIT":"api.service","_HOSTNAME":"vm-1","MESSAGE":"connection refused"}`,
+			wantKeys: map[string]any{
+				"timestamp": ts.Format(JournalTimeLayout),
+				"severity":  "ERROR",
+				"unit":      "api.service",
+				"host":      "vm-1",
+				"message":   "connection refused",
+				"PRIORITY":  "3",
+			},
+		},
+		{
+			name:      "Syslog identifier without a unit",
+			givenLine: `{"PRIORITY":"6","SYSLOG_IDENTIFIER":"sshd","MESSAGE":"Accepted publickey"}`,
+			wantKeys: map[string]any{
+				"severity": "INFO",
+				"unit":     "sshd",
+				"message":  "Accepted publickey",
+			},
+		},
+		{
+			name:      "Binary message",
+			givenLine: `{"PRIORITY":"7","_SYSTEMD_UNIT":"api.service","MESSAGE":[104,105,27]}`,
+			wantKeys: map[string]any{
+				"severity": "DEBUG",
+				"message":  "hi\x1b",
+			},
+		},
+		{
+			name:      "Field set several times",
+			givenLine: `{"_SYSTEMD_UNIT":"api.service","MESSAGE":["first","second"]}`,
+			wantKeys: map[string]any{
+				"message": "first",
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			m := make(map[string]any)
+			assert.NoError(t, json.Unmarshal([]byte(JournalEntry(test.givenLine)), &m))
+			for k, v := range test.wantKeys {
+				assert.Equal(t, v, m[k], k)
+			}
+		})
+	}
+	t.Run("Test not a journal entry", func(t *testing.T) {
+		assert.Equal(t, "-- No entries --", JournalEntry("-- No entries --"))
+	})
+}
+
+func TestJournalStream_StreamInto(t *testing.T) {
+	file := path.Join(t.TempDir(), "journal.json")
+	assert.NoError(t, os.WriteFile(file, []byte(
+		`{"PRIORITY":"4","_SYSTEMD_UNIT":"api.service","MESSAGE":"slow"}`+"\n"), 0644))
+	var gotArgs []string
+	journalCommand = func(args ...string) *exec.Cmd {
+		gotArgs = args
+		return exec.Command("cat", file)
+	}
+	defer func() {
+		journalCommand = func(args ...string) *exec.Cmd {
+			return exec.Command("journalctl", args...)
+		}
+	}()
+
+	r := MakeJournalReader(JournalOptions{Units: []string{"api.service", "db.service"}, Since: "-1h", Lines: 50}, nil)
+	assert.NoError(t, r.StreamInto())
+	defer r.Close()
+	m := make(map[string]any)
+	select {
+	case line := <-r.ChanReader():
+		assert.NoError(t, json.Unmarshal([]byte(line), &m))
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out")
+	}
+	assert.Equal(t, "WARN", m["severity"])
+	assert.Equal(t, "slow", m["message"])
+	assert.Equal(t, []string{"--output=json", "--follow", "--no-pager",
+		"--unit=api.service", "--unit=db.service", "--since=-1h", "--lines=50"}, gotArgs)
+}
+
+func TestJournalStream_ReadError(t *testing.T) {
+	file := path.Join(t.TempDir(), "journal.json")
+	// a single line beyond the largest entry the reader accepts
+	assert.NoError(t, os.WriteFile(file, bytes.Repeat([]byte("a"), 9*1024*1024), 0644))
+	journalCommand = func(args ...string) *exec.Cmd {
+		return exec.Command("cat", file)
+	}
+	defer func() {
+		journalCommand = func(args ...string) *exec.Cmd {
+			return exec.Command("journalctl", args...)
+		}
+	}()
+
+	r := MakeJournalReader(JournalOptions{}, nil)
+	errs := make(chan error, 1)
+	r.ErrorNotifier(func(err error) {
+		errs <- err
+	})
+	assert.NoError(t, r.StreamInto())
+	defer r.Close()
+	select {
+	case err := <-errs:
+		assert.ErrorIs(t, err, bufio.ErrTooLong)
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out")
+	}
+}
@@ -0,0 +1,220 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package reader
+
+import (
+	"bufio"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aurc/loggo/internal/util"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes"
+)
+
+// Keys injected into every entry streamed from Kubernetes.
+const (
+	K8sPod       = "pod"
+	K8sContainer = "container"
+	K8sNamespace = "namespace"
+)
+
+// K8sOptions selects the pods and containers streamed by MakeK8sReader.
+type K8sOptions struct {
+	// Namespace of the pods, all namespaces if empty.
+	Namespace string
+	// Selector is a label selector, e.g. "app=api,tier!=canary".
+	Selector string
+	// Container only streams the containers of this name, all if empty.
+	Container string
+	// Since only streams entries newer than this, the whole log if zero.
+	Since time.Duration
+}
+
+type k8sStream struct {
+	reader
+	*followers
+	client  kubernetes.Interface
+	options K8sOptions
+}
+
+// MakeK8sReader builds a streamer following the logs of every container of the
+// pods matching options, including pods created later (e.g. a new replica or
+// a pod that restarts), tagging each entry with its pod, container and
+// namespace.
+func MakeK8sReader(client kubernetes.Interface, options K8sOptions, strChan chan string) Reader {
+	if strChan == nil {
+		strChan = make(chan string, 1)
+	}
+	return &k8sStream{
+		reader: reader{
+			strChan:    strChan,
+			readerType: TypeK8s,
+		},
+		followers: newFollowers(strChan),
+		client:    client,
+		options:   options,
+	}
+}
+
+func (s *k8sStream) StreamInto() error {
+	// every container gets its own assembler, rules are checked up front
+	if _, err := newAssembler(s.multiline, nil); err != nil {
+		return err
+	}
+	w, err := s.listAndWatch()
+	if err != nil {
+		return err
+	}
+	s.wg.Add(1)
+	go s.watch(w)
+	return nil
+}
+
+// listAndWatch follows the pods currently matching and watches for changes
+// from then on.
+func (s *k8sStream) listAndWatch() (watch.Interface, error) {
+	pods := s.client.CoreV1().Pods(s.options.Namespace)
+	list, err := pods.List(s.ctx, metav1.ListOptions{LabelSelector: s.options.Selector})
+	if err != nil {
+		return nil, err
+	}
+	for i := range list.Items {
+		s.followPod(&list.Items[i])
+	}
+	return pods.Watch(s.ctx, metav1.ListOptions{
+		LabelSelector:   s.options.Selector,
+		ResourceVersion: list.ResourceVersion,
+	})
+}
+
+func (s *k8sStream) watch(w watch.Interface) {
+	defer s.wg.Done()
+	for {
+		select {
+		case <-s.ctx.Done():
+			w.Stop()
+			return
+		case ev, ok := <-w.ResultChan():
+			if !ok {
+				// the api server closes watches every now and then
+				var err error
+				if w, err = s.listAndWatch(); err != nil {
+					if s.ctx.Err() == nil && s.onError != nil {
+						s.onError(err)
+					}
+					return
+				}
+				continue
+			}
+			pod, ok := ev.Object.(*corev1.Pod)
+			if !ok {
+				continue
+			}
+			switch ev.Type {
+			case watch.Added, watch.Modified:
+				s.followPod(pod)
+			case watch.Deleted:
+				s.forgetPod(pod)
+			}
+		}
+	}
+}
+
+// followPod streams the running containers of pod not streamed yet. A
+// restarted container has a new id, so it's streamed afresh.
+func (s *k8sStream) followPod(pod *corev1.Pod) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for _, c := range pod.Status.ContainerStatuses {
+		if len(s.options.Container) > 0 && c.Name != s.options.Container {
+			continue
+		}
+		if c.State.Running == nil {
+			continue
+		}
+		id := containerKey(pod, c.Name) + c.ContainerID
+		if s.following[id] {
+			continue
+		}
+		s.following[id] = true
+		s.follow(pod.Namespace, pod.Name, c.Name)
+	}
+}
+
+func (s *k8sStream) forgetPod(pod *corev1.Pod) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	prefix := containerKey(pod, "")
+	for id := range s.following {
+		if strings.HasPrefix(id, prefix) {
+			delete(s.following, id)
+		}
+	}
+}
+
+func containerKey(pod *corev1.Pod, container string) string {
+	return fmt.Sprintf("%s/%s/%s", pod.Namespace, pod.Name, container)
+}
+
+// follow streams a container log. The caller must hold the lock.
+func (s *k8sStream) follow(namespace, pod, container string) {
+	tags := map[string]string{
+		K8sPod:       pod,
+		K8sContainer: container,
+		K8sNamespace: namespace,
+	}
+	a := s.assembler(s.multiline, tags)
+	opts := &corev1.PodLogOptions{Container: container, Follow: true}
+	if s.options.Since > 0 {
+		since := int64(s.options.Since.Seconds())
+		opts.SinceSeconds = &since
+	}
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		defer s.forget(a)
+		stream, err := s.client.CoreV1().Pods(namespace).GetLogs(pod, opts).Stream(s.ctx)
+		if err != nil {
+			if s.ctx.Err() == nil {
+				util.Log().WithField("container", container).Warnf("Unable to stream %s/%s: %v", namespace, pod, err)
+			}
+			return
+		}
+		defer stream.Close()
+		scanner := bufio.NewScanner(stream)
+		scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+		for scanner.Scan() {
+			a.Add(scanner.Text())
+		}
+		a.Flush()
+	}()
+}
+
+func (s *k8sStream) Close() {
+	s.followers.close()
+}
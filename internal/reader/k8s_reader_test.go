@@ -0,0 +1,162 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package reader
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/aurc/loggo/internal/config"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func runningPod(namespace, name string, labels map[string]string, containers ...string) *corev1.Pod {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name, Labels: labels},
+	}
+	for _, c := range containers {
+		pod.Spec.Containers = append(pod.Spec.Containers, corev1.Container{Name: c})
+		pod.Status.ContainerStatuses = append(pod.Status.ContainerStatuses, corev1.ContainerStatus{
+			Name:        c,
+			ContainerID: "containerd://" + name + "-" + c,
+			State:       corev1.ContainerState{Running: &corev1.ContainerStateRunning{}},
+		})
+	}
+	return pod
+}
+
+func TestK8sStream_StreamInto(t *testing.T) {
+	api := map[string]string{"app": "api"}
+	tests := []struct {
+		name         string
+		givenOptions K8sOptions
+		givenPods    []*corev1.Pod
+		givenLater   []*corev1.Pod
+		wantEntries  []map[string]any
+	}{
+		{
+			name:         "Streams every container of the selected pods",
+			givenOptions: K8sOptions{Namespace: "prod", Selector: "app=api"},
+			givenPods: []*corev1.Pod{
+				runningPod("prod", "api-0", api, "app", "sidecar"),
+				runningPod("prod", "web-0", map[string]string{"app": "web"}, "app"),
+				runningPod("test", "api-0", api, "app"),
+			},
+			wantEntries: []map[string]any{
+				{K8sPod: "api-0", K8sContainer: "app", K8sNamespace: "prod"},
+				{K8sPod: "api-0", K8sContainer: "sidecar", K8sNamespace: "prod"},
+			},
+		},
+		{
+			name:         "Only the given container",
+			givenOptions: K8sOptions{Selector: "app=api", Container: "app"},
+			givenPods: []*corev1.Pod{
+				runningPod("prod", "api-0", api, "app", "sidecar"),
+				runningPod("test", "api-0", api, "app"),
+			},
+			wantEntries: []map[string]any{
+				{K8sPod: "api-0", K8sContainer: "app", K8sNamespace: "prod"},
+				{K8sPod: "api-0", K8sContainer: "app", K8sNamespace: "test"},
+			},
+		},
+		{
+			name:         "Follows pods created later",
+			givenOptions: K8sOptions{Namespace: "prod"},
+			givenPods: []*corev1.Pod{
+				runningPod("prod", "api-0", api, "app"),
+			},
+			givenLater: []*corev1.Pod{
+				runningPod("prod", "api-1", api, "app"),
+			},
+			wantEntries: []map[string]any{
+				{K8sPod: "api-0", K8sContainer: "app", K8sNamespace: "prod"},
+				{K8sPod: "api-1", K8sContainer: "app", K8sNamespace: "prod"},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			client := fake.NewClientset()
+			for _, p := range test.givenPods {
+				_, err := client.CoreV1().Pods(p.Namespace).Create(context.Background(), p, metav1.CreateOptions{})
+				assert.NoError(t, err)
+			}
+			r := MakeK8sReader(client, test.givenOptions, nil)
+			assert.NoError(t, r.StreamInto())
+			defer r.Close()
+			for _, p := range test.givenLater {
+				_, err := client.CoreV1().Pods(p.Namespace).Create(context.Background(), p, metav1.CreateOptions{})
+				assert.NoError(t, err)
+			}
+
+			var entries []map[string]any
+			timeout := time.After(5 * time.Second)
+			for len(entries) < len(test.wantEntries) {
+				select {
+				case line := <-r.ChanReader():
+					m := make(map[string]any)
+					assert.NoError(t, json.Unmarshal([]byte(line), &m))
+					// the fake clientset streams "fake logs" for every container
+					assert.Equal(t, "fake logs", m[config.TextPayload])
+					delete(m, config.TextPayload)
+					delete(m, config.ParseErr)
+					entries = append(entries, m)
+				case <-timeout:
+					t.Fatalf("timed out, got %v", entries)
+				}
+			}
+			assert.ElementsMatch(t, test.wantEntries, entries)
+		})
+	}
+	t.Run("Test restarted container is streamed again", func(t *testing.T) {
+		pod := runningPod("prod", "api-0", api, "app")
+		client := fake.NewClientset(pod)
+		r := MakeK8sReader(client, K8sOptions{}, nil)
+		assert.NoError(t, r.StreamInto())
+		defer r.Close()
+		<-r.ChanReader()
+
+		pod = pod.DeepCopy()
+		pod.Status.ContainerStatuses[0].ContainerID = "containerd://restarted"
+		pod.Status.ContainerStatuses[0].RestartCount = 1
+		_, err := client.CoreV1().Pods("prod").UpdateStatus(context.Background(), pod, metav1.UpdateOptions{})
+		assert.NoError(t, err)
+		select {
+		case <-r.ChanReader():
+		case <-time.After(5 * time.Second):
+			t.Fatal("restarted container not streamed")
+		}
+		// both logs ended, nothing is kept for their streams
+		s := r.(*k8sStream)
+		assert.Eventually(t, func() bool {
+			s.lock.Lock()
+			defer s.lock.Unlock()
+			return len(s.assemblers) == 0
+		}, 5*time.Second, 10*time.Millisecond)
+	})
+}
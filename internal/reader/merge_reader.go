@@ -0,0 +1,306 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package reader
+
+import (
+	"container/heap"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/aurc/loggo/internal/config"
+	"github.com/aurc/loggo/internal/decoder"
+)
+
+// DefaultMergeWindow is how long the merged stream holds entries back waiting
+// for earlier ones from slower sources.
+const DefaultMergeWindow = 2 * time.Second
+
+// mergeTimeKeys are tried in turn when no time key is given.
+var mergeTimeKeys = []*config.Key{
+	{Name: "timestamp", Type: config.TypeDateTime},
+	{Name: "time", Type: config.TypeDateTime},
+	{Name: "ts", Type: config.TypeDateTime},
+	{Name: "@timestamp", Type: config.TypeDateTime},
+}
+
+// MergeSource is one of the streams combined by MakeMergedReader. Its entries
+// are tagged with Name under the config.Source key, unless the source reader
+// tagged them already (e.g. a glob of files).
+type MergeSource struct {
+	Name   string
+	Reader Reader
+}
+
+type mergedStream struct {
+	reader
+	sources  []MergeSource
+	timeKeys []*config.Key
+	window   time.Duration
+	decoder  decoder.Chain
+	in       chan mergeEvent
+	wg       sync.WaitGroup
+	done     chan struct{}
+	closed   sync.Once
+}
+
+// mergeEvent carries an entry read from a source, or the end of that source
+// when entry is nil.
+type mergeEvent struct {
+	source int
+	entry  *mergeEntry
+}
+
+type mergeEntry struct {
+	line    string
+	at      time.Time
+	order   int64
+	source  int
+	arrived time.Time
+}
+
+// MakeMergedReader combines several readers (files, pipe, GCP) into a single
+// stream ordered by the timeKey of each entry. An entry is released as soon as
+// every source still streaming has one waiting (so nothing earlier can come),
+// or once it has been held for the reorder window, so live tails of quiet
+// sources don't stall the others. Entries without a timestamp keep the time
+// of the previous entry of their source, e.g. the lines of a stack trace.
+// Without a timeKey, RFC 3339 values of the usual keys (timestamp, time, ts,
+// @timestamp) are used.
+func MakeMergedReader(sources []MergeSource, timeKey *config.Key, window time.Duration, strChan chan string) Reader {
+	if strChan == nil {
+		strChan = make(chan string, 1)
+	}
+	if window <= 0 {
+		window = DefaultMergeWindow
+	}
+	timeKeys := mergeTimeKeys
+	if timeKey != nil {
+		timeKeys = []*config.Key{timeKey}
+	}
+	return &mergedStream{
+		reader: reader{
+			strChan:    strChan,
+			readerType: TypeFile,
+		},
+		sources:  sources,
+		timeKeys: timeKeys,
+		window:   window,
+		decoder:  decoder.Default(),
+		in:       make(chan mergeEvent),
+		done:     make(chan struct{}),
+	}
+}
+
+func (s *mergedStream) StreamInto() error {
+	if len(s.sources) == 0 {
+		return fmt.Errorf("no sources to merge")
+	}
+	for i, src := range s.sources {
+		src.Reader.ErrorNotifier(func(err error) {
+			if s.onError != nil {
+				s.onError(fmt.Errorf("%s: %w", src.Name, err))
+			}
+		})
+		if s.multiline != nil {
+			src.Reader.Multiline(s.multiline)
+		}
+		if err := src.Reader.StreamInto(); err != nil {
+			for _, started := range s.sources[:i] {
+				started.Reader.Close()
+			}
+			return fmt.Errorf("%s: %w", src.Name, err)
+		}
+	}
+	for i, src := range s.sources {
+		s.wg.Add(1)
+		go s.consume(i, src)
+	}
+	s.wg.Add(1)
+	go s.merge()
+	return nil
+}
+
+// consume reads a source, stamping each entry with its time and tag.
+func (s *mergedStream) consume(index int, src MergeSource) {
+	defer s.wg.Done()
+	var last time.Time
+	send := func(e mergeEvent) bool {
+		select {
+		case s.in <- e:
+			return true
+		case <-s.done:
+			return false
+		}
+	}
+	for line := range src.Reader.ChanReader() {
+		if len(line) == 0 {
+			continue
+		}
+		out, at := s.stamp(line, src.Name)
+		if at.IsZero() {
+			at = last
+		} else {
+			last = at
+		}
+		if !send(mergeEvent{source: index, entry: &mergeEntry{
+			line:   out,
+			at:     at,
+			source: index,
+		}}) {
+			return
+		}
+	}
+	send(mergeEvent{source: index})
+}
+
+// stamp tags a line with the source name and extracts its time. Lines that
+// aren't json are wrapped as unparsed entries so the tag is kept; the log
+// view decodes them further.
+func (s *mergedStream) stamp(line, name string) (string, time.Time) {
+	m, ok := decoder.JSON().Decode(line)
+	decoded := m
+	if !ok {
+		m = decoder.Unparsed(line, "not a json object")
+		decoded = s.decoder.Decode(line)
+	}
+	var at time.Time
+	for _, k := range s.timeKeys {
+		if t, ok := k.ExtractTime(decoded); ok {
+			at = t
+			break
+		}
+	}
+	if _, tagged := m[config.Source]; !tagged {
+		m[config.Source] = name
+	}
+	b, err := json.Marshal(m)
+	if err != nil {
+		return line, at
+	}
+	return string(b), at
+}
+
+// merge orders the entries of every source and releases them into strChan.
+func (s *mergedStream) merge() {
+	defer s.wg.Done()
+	pending := &mergeHeap{}
+	waiting := make([]int, len(s.sources))
+	open := len(s.sources)
+	streaming := make([]bool, len(s.sources))
+	for i := range streaming {
+		streaming[i] = true
+	}
+	var arrivals int64
+	timer := time.NewTimer(s.window)
+	defer timer.Stop()
+
+	// ready tells whether the earliest entry can be released: every source
+	// still streaming has an entry waiting, or it waited for long enough.
+	ready := func(now time.Time) bool {
+		if now.Sub((*pending)[0].arrived) >= s.window {
+			return true
+		}
+		for i, n := range waiting {
+			if streaming[i] && n == 0 {
+				return false
+			}
+		}
+		return true
+	}
+	release := func() bool {
+		now := time.Now()
+		for pending.Len() > 0 && ready(now) {
+			e := heap.Pop(pending).(*mergeEntry)
+			waiting[e.source]--
+			select {
+			case s.strChan <- e.line:
+			case <-s.done:
+				return false
+			}
+		}
+		if pending.Len() > 0 {
+			timer.Reset(s.window - now.Sub((*pending)[0].arrived))
+		}
+		return true
+	}
+
+	for open > 0 || pending.Len() > 0 {
+		select {
+		case <-s.done:
+			return
+		case ev := <-s.in:
+			if ev.entry == nil {
+				streaming[ev.source] = false
+				open--
+			} else {
+				arrivals++
+				ev.entry.order = arrivals
+				ev.entry.arrived = time.Now()
+				heap.Push(pending, ev.entry)
+				waiting[ev.source]++
+			}
+		case <-timer.C:
+		}
+		if !release() {
+			return
+		}
+	}
+}
+
+func (s *mergedStream) Close() {
+	s.closed.Do(func() {
+		close(s.done)
+		for _, src := range s.sources {
+			src.Reader.Close()
+		}
+		s.wg.Wait()
+		close(s.strChan)
+	})
+}
+
+// mergeHeap orders entries by time, then by arrival.
+type mergeHeap []*mergeEntry
+
+func (h mergeHeap) Len() int { return len(h) }
+
+func (h mergeHeap) Less(i, j int) bool {
+	if !h[i].at.Equal(h[j].at) {
+		return h[i].at.Before(h[j].at)
+	}
+	return h[i].order < h[j].order
+}
+
+func (h mergeHeap) Swap(i, j int) { h[i], h[j] = h[j], h[i] }
+
+func (h *mergeHeap) Push(x any) { *h = append(*h, x.(*mergeEntry)) }
+
+func (h *mergeHeap) Pop() any {
+	old := *h
+	n := len(old)
+	e := old[n-1]
+	old[n-1] = nil
+	*h = old[:n-1]
+	return e
+}
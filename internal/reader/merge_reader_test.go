@@ -0,0 +1,173 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package reader
+
+import (
+	"encoding/json"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aurc/loggo/internal/config"
+	"github.com/stretchr/testify/assert"
+)
+
+// lineSource streams a fixed set of lines, optionally leaving the stream open.
+type lineSource struct {
+	reader
+	lines []string
+	delay time.Duration
+	keep  bool
+	once  sync.Once
+}
+
+func newLineSource(delay time.Duration, keep bool, lines ...string) *lineSource {
+	return &lineSource{
+		reader: reader{strChan: make(chan string)},
+		lines:  lines,
+		delay:  delay,
+		keep:   keep,
+	}
+}
+
+func (s *lineSource) StreamInto() error {
+	go func() {
+		time.Sleep(s.delay)
+		for _, l := range s.lines {
+			s.strChan <- l
+		}
+		if !s.keep {
+			s.Close()
+		}
+	}()
+	return nil
+}
+
+func (s *lineSource) Close() {
+	s.once.Do(func() {
+		close(s.strChan)
+	})
+}
+
+func TestMergedStream_StreamInto(t *testing.T) {
+	tests := []struct {
+		name        string
+		givenWindow time.Duration
+		givenA      *lineSource
+		givenB      *lineSource
+		wantLines   []string
+		wantSources []string
+	}{
+		{
+			name:        "Interleaves sources by time",
+			givenWindow: time.Minute,
+			givenA: newLineSource(0, false,
+				`{"ts":"2023-01-02T10:00:00Z","msg":"a1"}`,
+				`{"ts":"2023-01-02T10:00:02Z","msg":"a2"}`,
+				`{"ts":"2023-01-02T10:00:04Z","msg":"a3"}`),
+			givenB: newLineSource(50*time.Millisecond, false,
+				`{"ts":"2023-01-02T10:00:01Z","msg":"b1"}`,
+				`{"ts":"2023-01-02T10:00:03Z","msg":"b2"}`),
+			wantLines:   []string{"a1", "b1", "a2", "b2", "a3"},
+			wantSources: []string{"a", "b", "a", "b", "a"},
+		},
+		{
+			name:        "Entries without time follow the previous entry of their source",
+			givenWindow: time.Minute,
+			givenA: newLineSource(0, false,
+				`{"ts":"2023-01-02T10:00:00Z","msg":"a1"}`,
+				`{"ts":"2023-01-02T10:00:02Z","msg":"a2"}`,
+				`  at Main.java:10`),
+			givenB: newLineSource(0, false,
+				`{"ts":"2023-01-02T10:00:03Z","msg":"b1"}`),
+			wantLines:   []string{"a1", "a2", "  at Main.java:10", "b1"},
+			wantSources: []string{"a", "a", "a", "b"},
+		},
+		{
+			name:        "Quiet live source is waited for the reorder window only",
+			givenWindow: 200 * time.Millisecond,
+			givenA: newLineSource(0, false,
+				`{"ts":"2023-01-02T10:00:05Z","msg":"a1"}`),
+			givenB:      newLineSource(0, true),
+			wantLines:   []string{"a1"},
+			wantSources: []string{"a"},
+		},
+		{
+			name:        "Keeps the source tag of the source reader",
+			givenWindow: time.Minute,
+			givenA: newLineSource(0, false,
+				`{"ts":"2023-01-02T10:00:00Z","msg":"a1","$_source":"app-1.log"}`),
+			givenB:      newLineSource(0, false),
+			wantLines:   []string{"a1"},
+			wantSources: []string{"app-1.log"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := MakeMergedReader([]MergeSource{
+				{Name: "a", Reader: test.givenA},
+				{Name: "b", Reader: test.givenB},
+			}, &config.Key{Name: "ts", Type: config.TypeDateTime}, test.givenWindow, nil)
+			assert.NoError(t, r.StreamInto())
+			defer r.Close()
+
+			var lines, sources []string
+			timeout := time.After(5 * time.Second)
+			for len(lines) < len(test.wantLines) {
+				select {
+				case line := <-r.ChanReader():
+					m := make(map[string]any)
+					assert.NoError(t, json.Unmarshal([]byte(line), &m))
+					if msg, ok := m["msg"]; ok {
+						lines = append(lines, msg.(string))
+					} else {
+						lines = append(lines, m[config.TextPayload].(string))
+					}
+					sources = append(sources, m[config.Source].(string))
+				case <-timeout:
+					t.Fatalf("timed out, got %v", lines)
+				}
+			}
+			assert.Equal(t, test.wantLines, lines)
+			assert.Equal(t, test.wantSources, sources)
+		})
+	}
+	t.Run("Test usual time keys without a time key", func(t *testing.T) {
+		r := MakeMergedReader([]MergeSource{
+			{Name: "a", Reader: newLineSource(0, false, `{"time":"2023-01-02T10:00:02Z","msg":"a1"}`)},
+			{Name: "b", Reader: newLineSource(0, false, `{"@timestamp":"2023-01-02T10:00:01Z","msg":"b1"}`)},
+		}, nil, time.Minute, nil)
+		assert.NoError(t, r.StreamInto())
+		defer r.Close()
+		var lines []string
+		for range 2 {
+			m := make(map[string]any)
+			assert.NoError(t, json.Unmarshal([]byte(<-r.ChanReader()), &m))
+			lines = append(lines, m["msg"].(string))
+		}
+		assert.Equal(t, []string{"b1", "a1"}, lines)
+	})
+	t.Run("Test no sources", func(t *testing.T) {
+		assert.Error(t, MakeMergedReader(nil, nil, 0, nil).StreamInto())
+	})
+}
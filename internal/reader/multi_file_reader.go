@@ -0,0 +1,187 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package reader
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/aurc/loggo/internal/config"
+	"github.com/aurc/loggo/internal/decoder"
+	"github.com/nxadm/tail"
+)
+
+const defaultRescanInterval = 2 * time.Second
+
+type multiFileStream struct {
+	reader
+	patterns       []string
+	rescanInterval time.Duration
+	tails          map[string]*tail.Tail
+	assemblers     []*assembler
+	lock           sync.Mutex
+	wg             sync.WaitGroup
+	done           chan struct{}
+}
+
+// StreamInto tails every file matching the configured patterns and keeps
+// looking for new matches, so files created after the stream started (e.g. a
+// new replica's log) are picked up as they appear.
+func (s *multiFileStream) StreamInto() error {
+	files, err := s.matches()
+	if err != nil {
+		return err
+	}
+	if len(files) == 0 {
+		return fmt.Errorf("no files match %v", s.patterns)
+	}
+	for _, f := range files {
+		if err := s.follow(f); err != nil {
+			return err
+		}
+	}
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		ticker := time.NewTicker(s.rescanInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-s.done:
+				return
+			case <-ticker.C:
+				files, err := s.matches()
+				if err != nil {
+					if s.onError != nil {
+						s.onError(err)
+					}
+					return
+				}
+				for _, f := range files {
+					if err := s.follow(f); err != nil && s.onError != nil {
+						s.onError(err)
+					}
+				}
+			}
+		}
+	}()
+	return nil
+}
+
+func (s *multiFileStream) matches() ([]string, error) {
+	var files []string
+	seen := make(map[string]bool)
+	for _, p := range s.patterns {
+		m, err := filepath.Glob(p)
+		if err != nil {
+			return nil, fmt.Errorf("bad file pattern %s: %w", p, err)
+		}
+		for _, f := range m {
+			if !seen[f] {
+				seen[f] = true
+				files = append(files, f)
+			}
+		}
+	}
+	return files, nil
+}
+
+func (s *multiFileStream) follow(fileName string) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if _, ok := s.tails[fileName]; ok {
+		return nil
+	}
+	a, err := newAssembler(s.multiline, func(record string) {
+		select {
+		case s.strChan <- tagLine(record, config.Source, fileName):
+		case <-s.done:
+		}
+	})
+	if err != nil {
+		return err
+	}
+	t, err := tail.TailFile(fileName, tail.Config{Follow: true, Poll: true})
+	if err != nil {
+		return err
+	}
+	s.tails[fileName] = t
+	s.assemblers = append(s.assemblers, a)
+
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+		for line := range t.Lines {
+			select {
+			case <-s.done:
+				return
+			default:
+				a.Add(line.Text)
+			}
+		}
+	}()
+	return nil
+}
+
+func (s *multiFileStream) Close() {
+	close(s.done)
+	s.lock.Lock()
+	for _, t := range s.tails {
+		t.Kill(fmt.Errorf("stopped by Close method"))
+	}
+	for _, a := range s.assemblers {
+		a.Stop()
+	}
+	s.lock.Unlock()
+	s.wg.Wait()
+	close(s.strChan)
+}
+
+// tagLine adds key=value to a log line. Lines that aren't json are wrapped as
+// unparsed entries so the tag is kept; the log view decodes them further.
+func tagLine(line, key, value string) string {
+	return tagLineWith(line, map[string]string{key: value})
+}
+
+// tagLineWith adds every key=value of tags to a log line, as tagLine does.
+func tagLineWith(line string, tags map[string]string) string {
+	if len(line) == 0 {
+		return line
+	}
+	m, ok := decoder.JSON().Decode(line)
+	if !ok {
+		m = decoder.Unparsed(line, "not a json object")
+	}
+	for k, v := range tags {
+		m[k] = v
+	}
+	b, err := json.Marshal(m)
+	if err != nil {
+		return line
+	}
+	return string(b)
+}
@@ -0,0 +1,83 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package reader
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/aurc/loggo/internal/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMultiFileStream_StreamInto(t *testing.T) {
+	t.Run("Test glob stream picks up new files and tags source", func(t *testing.T) {
+		tmpDir := t.TempDir()
+		appendLine := func(fileName, line string) {
+			file, err := os.OpenFile(path.Join(tmpDir, fileName), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+			assert.NoError(t, err)
+			_, err = file.WriteString(line + "\n")
+			assert.NoError(t, err)
+			assert.NoError(t, file.Close())
+		}
+		appendLine("app-1.log", `{"msg":"first"}`)
+		appendLine("app-2.log", `plain text`)
+		appendLine("other.txt", `{"msg":"ignored"}`)
+
+		streamReceiver := make(chan string, 1)
+		reader := MakeFilesReader([]string{path.Join(tmpDir, "app-*.log")}, streamReceiver)
+		reader.(*multiFileStream).rescanInterval = 100 * time.Millisecond
+		assert.NoError(t, reader.StreamInto())
+		go func() {
+			time.Sleep(500 * time.Millisecond)
+			appendLine("app-3.log", `{"msg":"late"}`)
+			time.Sleep(time.Second)
+			reader.Close()
+		}()
+
+		sources := make(map[string]map[string]any)
+		for line := range streamReceiver {
+			m := make(map[string]any)
+			assert.NoError(t, json.Unmarshal([]byte(line), &m))
+			sources[path.Base(fmt.Sprint(m[config.Source]))] = m
+		}
+
+		assert.Len(t, sources, 3)
+		assert.Equal(t, "first", sources["app-1.log"]["msg"])
+		assert.Equal(t, "plain text", sources["app-2.log"][config.TextPayload])
+		assert.Contains(t, sources["app-2.log"], config.ParseErr)
+		assert.Equal(t, "late", sources["app-3.log"]["msg"])
+	})
+	t.Run("Test no matching files", func(t *testing.T) {
+		reader := MakeFilesReader([]string{path.Join(t.TempDir(), "*.log")}, nil)
+		assert.Error(t, reader.StreamInto())
+	})
+	t.Run("Test single plain file keeps the file streamer", func(t *testing.T) {
+		reader := MakeFilesReader([]string{"app.log"}, nil)
+		assert.IsType(t, &fileStream{}, reader)
+	})
+}
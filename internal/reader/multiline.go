@@ -0,0 +1,197 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package reader
+
+import (
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	defaultFlushAfter = 500 * time.Millisecond
+	defaultMaxLines   = 500
+)
+
+// MultilineRules configures how consecutive lines are assembled into a single
+// record before being streamed, so that a stack trace or a pretty printed json
+// document is delivered as one entry.
+type MultilineRules struct {
+	// Continuation is a regular expression; lines matching it are appended to
+	// the previous record, e.g. `^(\s+at |Caused by:)` for java stack traces.
+	Continuation string
+	// Indented appends lines starting with a space or tab to the previous record.
+	Indented bool
+	// JSON balances curly braces so a json object spanning several lines is
+	// assembled as one record.
+	JSON bool
+	// FlushAfter is how long a pending record waits for more lines before it's
+	// streamed. Defaults to 500ms.
+	FlushAfter time.Duration
+	// MaxLines caps the number of lines in a record. Defaults to 500.
+	MaxLines int
+}
+
+// assembler groups raw lines into records according to MultilineRules. With
+// no rules every non-empty line is a record of its own.
+type assembler struct {
+	rules        *MultilineRules
+	continuation *regexp.Regexp
+	emit         func(record string)
+	pending      []string
+	depth        int
+	inString     bool
+	escaped      bool
+	timer        *time.Timer
+	stopped      bool
+	lock         sync.Mutex
+}
+
+func newAssembler(rules *MultilineRules, emit func(record string)) (*assembler, error) {
+	a := &assembler{
+		rules: rules,
+		emit:  emit,
+	}
+	if rules != nil && len(rules.Continuation) > 0 {
+		var err error
+		if a.continuation, err = regexp.Compile(rules.Continuation); err != nil {
+			return nil, err
+		}
+	}
+	return a, nil
+}
+
+// Add feeds a raw line, emitting any record it completes.
+func (a *assembler) Add(line string) {
+	line = strings.TrimRight(line, "\r\n")
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	if a.stopped {
+		return
+	}
+	if a.rules == nil {
+		if len(line) > 0 {
+			a.emit(line)
+		}
+		return
+	}
+	switch {
+	case a.depth > 0:
+		a.pending = append(a.pending, line)
+		a.balance(line)
+	case len(line) == 0:
+		return
+	case len(a.pending) > 0 && a.continues(line):
+		a.pending = append(a.pending, line)
+	default:
+		a.flushLocked()
+		a.pending = append(a.pending, line)
+		if a.rules.JSON && strings.HasPrefix(strings.TrimSpace(line), "{") {
+			a.balance(line)
+		}
+	}
+	if a.depth == 0 && a.rules.JSON && strings.HasPrefix(strings.TrimSpace(a.pending[0]), "{") ||
+		len(a.pending) >= a.maxLines() {
+		a.flushLocked()
+		return
+	}
+	a.schedule()
+}
+
+func (a *assembler) continues(line string) bool {
+	if a.rules.Indented && (line[0] == ' ' || line[0] == '\t') {
+		return true
+	}
+	return a.continuation != nil && a.continuation.MatchString(line)
+}
+
+// balance tracks the curly braces depth, ignoring braces within json strings.
+func (a *assembler) balance(line string) {
+	for _, c := range line {
+		switch {
+		case a.escaped:
+			a.escaped = false
+		case a.inString && c == '\\':
+			a.escaped = true
+		case c == '"':
+			a.inString = !a.inString
+		case a.inString:
+		case c == '{':
+			a.depth++
+		case c == '}':
+			a.depth--
+		}
+	}
+	if a.depth < 0 {
+		a.depth = 0
+	}
+}
+
+func (a *assembler) maxLines() int {
+	if a.rules.MaxLines > 0 {
+		return a.rules.MaxLines
+	}
+	return defaultMaxLines
+}
+
+func (a *assembler) schedule() {
+	flushAfter := a.rules.FlushAfter
+	if flushAfter <= 0 {
+		flushAfter = defaultFlushAfter
+	}
+	if a.timer == nil {
+		a.timer = time.AfterFunc(flushAfter, a.Flush)
+	} else {
+		a.timer.Reset(flushAfter)
+	}
+}
+
+// Flush emits the pending record, if any.
+func (a *assembler) Flush() {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	if !a.stopped {
+		a.flushLocked()
+	}
+}
+
+func (a *assembler) flushLocked() {
+	if len(a.pending) > 0 {
+		a.emit(strings.Join(a.pending, "\n"))
+	}
+	a.pending = a.pending[:0]
+	a.depth = 0
+	a.inString = false
+	a.escaped = false
+}
+
+// Stop discards pending lines; nothing is emitted after it returns.
+func (a *assembler) Stop() {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	a.stopped = true
+	if a.timer != nil {
+		a.timer.Stop()
+	}
+}
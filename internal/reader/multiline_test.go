@@ -0,0 +1,133 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package reader
+
+import (
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAssembler_Add(t *testing.T) {
+	javaTrace := `2022-07-30 ERROR request failed
+java.lang.IllegalStateException: boom
+	at com.foo.Bar.baz(Bar.java:10)
+	at com.foo.Bar.main(Bar.java:5)
+Caused by: java.io.IOException: disk
+	... 2 more
+2022-07-30 INFO next request`
+	tests := []struct {
+		name         string
+		givenRules   *MultilineRules
+		givenLines   string
+		wantsRecords []string
+	}{
+		{
+			name:         "no rules streams every non empty line",
+			givenLines:   "a\n\nb\r\n",
+			wantsRecords: []string{"a", "b"},
+		},
+		{
+			name: "indented and continuation pattern",
+			givenRules: &MultilineRules{
+				Indented:     true,
+				Continuation: `^(Caused by:|java\.)`,
+			},
+			givenLines: javaTrace,
+			wantsRecords: []string{
+				strings.Join(strings.Split(javaTrace, "\n")[:6], "\n"),
+				"2022-07-30 INFO next request",
+			},
+		},
+		{
+			name:       "pretty printed json",
+			givenRules: &MultilineRules{JSON: true},
+			givenLines: "{\n  \"a\": \"}{\",\n  \"b\": {\n    \"c\": 1\n  }\n}\n{\"single\": true}\nplain",
+			wantsRecords: []string{
+				"{\n  \"a\": \"}{\",\n  \"b\": {\n    \"c\": 1\n  }\n}",
+				`{"single": true}`,
+				"plain",
+			},
+		},
+		{
+			name:       "max lines",
+			givenRules: &MultilineRules{Indented: true, MaxLines: 2},
+			givenLines: "a\n b\n c",
+			wantsRecords: []string{
+				"a\n b",
+				" c",
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var lock sync.Mutex
+			var records []string
+			a, err := newAssembler(test.givenRules, func(record string) {
+				lock.Lock()
+				defer lock.Unlock()
+				records = append(records, record)
+			})
+			assert.NoError(t, err)
+			for _, line := range strings.Split(test.givenLines, "\n") {
+				a.Add(line)
+			}
+			a.Flush()
+			a.Stop()
+			lock.Lock()
+			defer lock.Unlock()
+			assert.Equal(t, test.wantsRecords, records)
+		})
+	}
+}
+
+func TestAssembler_FlushAfter(t *testing.T) {
+	records := make(chan string, 2)
+	a, err := newAssembler(&MultilineRules{
+		Indented:   true,
+		FlushAfter: 50 * time.Millisecond,
+	}, func(record string) {
+		records <- record
+	})
+	assert.NoError(t, err)
+	a.Add("first")
+	a.Add("  continued")
+	select {
+	case r := <-records:
+		assert.Equal(t, "first\n  continued", r)
+	case <-time.After(time.Second):
+		assert.Fail(t, "pending record wasn't flushed")
+	}
+	a.Stop()
+	a.Add("dropped")
+	a.Flush()
+	assert.Len(t, records, 0)
+}
+
+func TestAssembler_BadPattern(t *testing.T) {
+	_, err := newAssembler(&MultilineRules{Continuation: `(`}, func(string) {})
+	assert.Error(t, err)
+}
@@ -31,7 +31,9 @@ import (
 
 type readPipeStream struct {
 	reader
-	stop bool
+	stop      bool
+	assembler *assembler
+	done      chan struct{}
 }
 
 func (s *readPipeStream) StreamInto() error {
@@ -43,6 +45,15 @@ func (s *readPipeStream) StreamInto() error {
 		return fmt.Errorf("nothing in input stream")
 	}
 
+	s.assembler, err = newAssembler(s.multiline, func(record string) {
+		select {
+		case s.strChan <- record:
+		case <-s.done:
+		}
+	})
+	if err != nil {
+		return err
+	}
 	reader := bufio.NewReader(os.Stdin)
 
 	go func() {
@@ -51,12 +62,14 @@ func (s *readPipeStream) StreamInto() error {
 			if err != nil {
 				time.Sleep(time.Second)
 			}
-			s.strChan <- str
+			s.assembler.Add(str)
 		}
 	}()
 	return nil
 }
 func (s *readPipeStream) Close() {
 	s.stop = true
+	close(s.done)
+	s.assembler.Stop()
 	close(s.strChan)
 }
@@ -22,10 +22,17 @@ THE SOFTWARE.
 
 package reader
 
+import (
+	"strings"
+
+	"github.com/nxadm/tail"
+)
+
 type reader struct {
 	strChan    chan string
 	readerType Type
 	onError    func(err error)
+	multiline  *MultilineRules
 }
 
 type Type = int64
@@ -34,6 +41,11 @@ const (
 	TypeFile = Type(iota)
 	TypePipe
 	TypeGCP
+	TypeK8s
+	TypeDocker
+	TypeJournal
+	TypeSyslog
+	TypeHTTP
 )
 
 // MakeReader builds a continues file/pipe streamer used to feed the logger. If
@@ -49,6 +61,7 @@ func MakeReader(fileName string, strChan chan string) Reader {
 				readerType: TypeFile,
 			},
 			fileName: fileName,
+			done:     make(chan struct{}),
 		}
 	}
 	return &readPipeStream{
@@ -56,9 +69,41 @@ func MakeReader(fileName string, strChan chan string) Reader {
 			strChan:    strChan,
 			readerType: TypePipe,
 		},
+		done: make(chan struct{}),
+	}
+}
+
+// MakeFilesReader builds a streamer for one or more file names or glob
+// patterns. A single plain file name behaves exactly like MakeReader, otherwise
+// every matching file is followed and each entry is tagged with its source file
+// under the config.Source key. New files matching the patterns are picked up as
+// they appear.
+func MakeFilesReader(patterns []string, strChan chan string) Reader {
+	if len(patterns) == 0 {
+		return MakeReader("", strChan)
+	}
+	if len(patterns) == 1 && !hasGlobMeta(patterns[0]) {
+		return MakeReader(patterns[0], strChan)
+	}
+	if strChan == nil {
+		strChan = make(chan string, 1)
+	}
+	return &multiFileStream{
+		reader: reader{
+			strChan:    strChan,
+			readerType: TypeFile,
+		},
+		patterns:       patterns,
+		rescanInterval: defaultRescanInterval,
+		tails:          make(map[string]*tail.Tail),
+		done:           make(chan struct{}),
 	}
 }
 
+func hasGlobMeta(pattern string) bool {
+	return strings.ContainsAny(pattern, `*?[`)
+}
+
 func (s *reader) ChanReader() <-chan string {
 	return s.strChan
 }
@@ -71,6 +116,10 @@ func (s *reader) Type() Type {
 	return s.readerType
 }
 
+func (s *reader) Multiline(rules *MultilineRules) {
+	s.multiline = rules
+}
+
 type Reader interface {
 	// StreamInto feeds the strChan channel for every streamed line.
 	StreamInto() error
@@ -80,4 +129,8 @@ type Reader interface {
 	ChanReader() <-chan string
 	// ErrorNotifier registers a callback func that's called upon fatal streaming log.
 	ErrorNotifier(onError func(err error))
+	// Multiline sets the rules to assemble several lines into a single entry.
+	// It only applies to line based readers (file and pipe) and must be set
+	// before StreamInto.
+	Multiline(rules *MultilineRules)
 }
@@ -0,0 +1,284 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package reader
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/aurc/loggo/internal/decoder"
+)
+
+// SyslogRemote is the key holding the address syslog messages came from.
+const SyslogRemote = "remote"
+
+// maxSyslogMessage caps the size of a single message.
+const maxSyslogMessage = 64 * 1024
+
+type syslogStream struct {
+	reader
+	listen   string
+	decoder  decoder.Decoder
+	packets  net.PacketConn
+	listener net.Listener
+	conns    map[net.Conn]bool
+	lock     sync.Mutex
+	wg       sync.WaitGroup
+	done     chan struct{}
+}
+
+// MakeSyslogReader builds a syslog server listening on listen, e.g.
+// "udp://:5514" or "tcp://127.0.0.1:5514" (udp if no scheme is given). RFC 5424
+// and RFC 3164 messages are decoded into entries, see decoder.Syslog, and
+// tagged with the address they came from; messages without a timestamp get
+// the time they were received. Over tcp, messages are either
+// newline delimited or octet counted (RFC 6587).
+func MakeSyslogReader(listen string, strChan chan string) Reader {
+	if strChan == nil {
+		strChan = make(chan string, 1)
+	}
+	return &syslogStream{
+		reader: reader{
+			strChan:    strChan,
+			readerType: TypeSyslog,
+		},
+		listen:  listen,
+		decoder: decoder.Syslog(),
+		conns:   make(map[net.Conn]bool),
+		done:    make(chan struct{}),
+	}
+}
+
+// ParseListen splits a listen address such as "udp://:5514" into network and
+// address.
+func ParseListen(listen string) (string, string, error) {
+	if !strings.Contains(listen, "://") {
+		return "udp", listen, nil
+	}
+	u, err := url.Parse(listen)
+	if err != nil {
+		return "", "", fmt.Errorf("bad listen address %s: %w", listen, err)
+	}
+	switch u.Scheme {
+	case "udp", "tcp":
+		return u.Scheme, u.Host, nil
+	}
+	return "", "", fmt.Errorf("bad listen address %s: use udp:// or tcp://", listen)
+}
+
+func (s *syslogStream) StreamInto() error {
+	network, address, err := ParseListen(s.listen)
+	if err != nil {
+		return err
+	}
+	s.wg.Add(1)
+	if network == "udp" {
+		if s.packets, err = net.ListenPacket(network, address); err != nil {
+			s.wg.Done()
+			return err
+		}
+		go s.serveUDP()
+	} else {
+		if s.listener, err = net.Listen(network, address); err != nil {
+			s.wg.Done()
+			return err
+		}
+		go s.serveTCP()
+	}
+	return nil
+}
+
+// Addr is the address the server listens on.
+func (s *syslogStream) Addr() net.Addr {
+	if s.packets != nil {
+		return s.packets.LocalAddr()
+	}
+	if s.listener != nil {
+		return s.listener.Addr()
+	}
+	return nil
+}
+
+func (s *syslogStream) serveUDP() {
+	defer s.wg.Done()
+	buf := make([]byte, maxSyslogMessage)
+	for {
+		n, addr, err := s.packets.ReadFrom(buf)
+		if err != nil {
+			s.failed(err)
+			return
+		}
+		if !s.emit(string(buf[:n]), addr) {
+			return
+		}
+	}
+}
+
+func (s *syslogStream) serveTCP() {
+	defer s.wg.Done()
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			s.failed(err)
+			return
+		}
+		s.lock.Lock()
+		select {
+		case <-s.done:
+			s.lock.Unlock()
+			conn.Close()
+			return
+		default:
+			s.conns[conn] = true
+		}
+		s.lock.Unlock()
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			defer func() {
+				s.lock.Lock()
+				delete(s.conns, conn)
+				s.lock.Unlock()
+				conn.Close()
+			}()
+			r := bufio.NewReaderSize(conn, maxSyslogMessage)
+			for {
+				msg, err := readFrame(r)
+				if len(msg) > 0 && !s.emit(msg, conn.RemoteAddr()) {
+					return
+				}
+				if err != nil {
+					return
+				}
+			}
+		}()
+	}
+}
+
+// readFrame reads a message either octet counted ("LEN SP MSG") or newline
+// delimited. Messages longer than maxSyslogMessage are truncated.
+func readFrame(r *bufio.Reader) (string, error) {
+	if n, ok := octetCount(r); ok {
+		msg := make([]byte, n)
+		_, err := io.ReadFull(r, msg)
+		return string(msg), err
+	}
+	return readLine(r, '\n')
+}
+
+// octetCount consumes the "LEN SP" prefix of an octet counted message, if the
+// next bytes are one.
+func octetCount(r *bufio.Reader) (int, bool) {
+	prefix, _ := r.Peek(len(strconv.Itoa(maxSyslogMessage)) + 1)
+	digits := 0
+	for digits < len(prefix) && prefix[digits] >= '0' && prefix[digits] <= '9' {
+		digits++
+	}
+	if digits == 0 || prefix[0] == '0' || digits == len(prefix) || prefix[digits] != ' ' {
+		return 0, false
+	}
+	n, err := strconv.Atoi(string(prefix[:digits]))
+	if err != nil || n > maxSyslogMessage {
+		return 0, false
+	}
+	_, _ = r.Discard(digits + 1)
+	return n, true
+}
+
+// readLine reads up to and including delim, keeping at most maxSyslogMessage
+// bytes and discarding the rest.
+func readLine(r *bufio.Reader, delim byte) (string, error) {
+	var line []byte
+	for {
+		chunk, err := r.ReadSlice(delim)
+		if room := maxSyslogMessage - len(line); room > 0 {
+			line = append(line, chunk[:min(len(chunk), room)]...)
+		}
+		if err != bufio.ErrBufferFull {
+			return string(line), err
+		}
+	}
+}
+
+// emit decodes a message into strChan, returning false once closed.
+func (s *syslogStream) emit(msg string, from net.Addr) bool {
+	msg = strings.TrimRight(msg, "\r\n\x00")
+	if len(msg) == 0 {
+		return true
+	}
+	m, ok := s.decoder.Decode(msg)
+	if !ok {
+		m = decoder.Unparsed(msg, "not a syslog message")
+	}
+	if _, ok := m[decoder.SyslogTimestamp]; !ok {
+		m[decoder.SyslogTimestamp] = time.Now().Format(time.RFC3339)
+	}
+	if host, _, err := net.SplitHostPort(from.String()); err == nil {
+		m[SyslogRemote] = host
+	}
+	b, err := json.Marshal(m)
+	if err != nil {
+		return true
+	}
+	select {
+	case s.strChan <- string(b):
+		return true
+	case <-s.done:
+		return false
+	}
+}
+
+func (s *syslogStream) failed(err error) {
+	select {
+	case <-s.done:
+	default:
+		if s.onError != nil {
+			s.onError(err)
+		}
+	}
+}
+
+func (s *syslogStream) Close() {
+	close(s.done)
+	if s.packets != nil {
+		s.packets.Close()
+	}
+	if s.listener != nil {
+		s.listener.Close()
+	}
+	s.lock.Lock()
+	for c := range s.conns {
+		c.Close()
+	}
+	s.lock.Unlock()
+	s.wg.Wait()
+	close(s.strChan)
+}
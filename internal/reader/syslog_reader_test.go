@@ -0,0 +1,196 @@
+/*
+Copyright © 2022 Aurelio Calegari, et al.
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+
+package reader
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aurc/loggo/internal/config"
+	"github.com/aurc/loggo/internal/decoder"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSyslogStream_StreamInto(t *testing.T) {
+	tests := []struct {
+		name         string
+		givenListen  string
+		givenPayload []string
+		wantMessages []string
+	}{
+		{
+			name:        "UDP datagrams",
+			givenListen: "udp://127.0.0.1:0",
+			givenPayload: []string{
+				`<34>Oct 11 22:14:15 mymachine su[230]: 'su root' failed`,
+				`<165>1 2003-10-11T22:14:15.003Z host app - - [id k="v"] structured`,
+			},
+			wantMessages: []string{"'su root' failed", "structured"},
+		},
+		{
+			name:        "TCP newline delimited",
+			givenListen: "tcp://127.0.0.1:0",
+			givenPayload: []string{
+				"<13>1 - host app - - - first\n<13>1 - host app - - - second\n",
+			},
+			wantMessages: []string{"first", "second"},
+		},
+		{
+			name:        "TCP octet counted",
+			givenListen: "tcp://127.0.0.1:0",
+			givenPayload: []string{
+				"33 <13>1 - host app - - - multi\nline",
+				"35 <13>1 - host app - - - the next one",
+			},
+			wantMessages: []string{"multi\nline", "the next one"},
+		},
+		{
+			name:         "Not syslog",
+			givenListen:  "udp://127.0.0.1:0",
+			givenPayload: []string{"plain text"},
+			wantMessages: []string{"plain text"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := MakeSyslogReader(test.givenListen, nil)
+			assert.NoError(t, r.StreamInto())
+			defer r.Close()
+			addr := r.(*syslogStream).Addr()
+			conn, err := net.Dial(addr.Network(), addr.String())
+			assert.NoError(t, err)
+			defer conn.Close()
+			for _, p := range test.givenPayload {
+				_, err := fmt.Fprint(conn, p)
+				assert.NoError(t, err)
+			}
+
+			var messages []string
+			timeout := time.After(5 * time.Second)
+			for len(messages) < len(test.wantMessages) {
+				select {
+				case line := <-r.ChanReader():
+					m := make(map[string]any)
+					assert.NoError(t, json.Unmarshal([]byte(line), &m))
+					assert.Equal(t, "127.0.0.1", m[SyslogRemote])
+					messages = append(messages, m[config.TextPayload].(string))
+				case <-timeout:
+					t.Fatalf("timed out, got %v", messages)
+				}
+			}
+			assert.Equal(t, test.wantMessages, messages)
+		})
+	}
+	t.Run("Test structured data", func(t *testing.T) {
+		r := MakeSyslogReader("udp://127.0.0.1:0", nil)
+		assert.NoError(t, r.StreamInto())
+		defer r.Close()
+		addr := r.(*syslogStream).Addr()
+		conn, err := net.Dial(addr.Network(), addr.String())
+		assert.NoError(t, err)
+		defer conn.Close()
+		_, err = fmt.Fprint(conn, `<165>1 2003-10-11T22:14:15.003Z host app - - [id k="v"] structured`)
+		assert.NoError(t, err)
+		m := make(map[string]any)
+		assert.NoError(t, json.Unmarshal([]byte(<-r.ChanReader()), &m))
+		assert.Equal(t, "v", (&config.Key{Name: decoder.SyslogData + "/id/k"}).ExtractValue(m))
+	})
+}
+
+func TestParseListen(t *testing.T) {
+	tests := []struct {
+		name        string
+		givenListen string
+		wantNetwork string
+		wantAddress string
+		wantError   bool
+	}{
+		{name: "UDP", givenListen: "udp://:5514", wantNetwork: "udp", wantAddress: ":5514"},
+		{name: "TCP", givenListen: "tcp://127.0.0.1:5514", wantNetwork: "tcp", wantAddress: "127.0.0.1:5514"},
+		{name: "No scheme", givenListen: ":5514", wantNetwork: "udp", wantAddress: ":5514"},
+		{name: "Unsupported scheme", givenListen: "http://:5514", wantError: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			network, address, err := ParseListen(test.givenListen)
+			if test.wantError {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, test.wantNetwork, network)
+			assert.Equal(t, test.wantAddress, address)
+		})
+	}
+}
+
+func TestReadFrame(t *testing.T) {
+	long := strings.Repeat("x", 3*maxSyslogMessage)
+	tests := []struct {
+		name       string
+		givenInput string
+		wantFrames []string
+	}{
+		{
+			name:       "Newline delimited",
+			givenInput: "first\nsecond\n",
+			wantFrames: []string{"first\n", "second\n"},
+		},
+		{
+			name:       "Octet counted",
+			givenInput: "5 first6 second",
+			wantFrames: []string{"first", "second"},
+		},
+		{
+			name:       "Line beyond the maximum is truncated",
+			givenInput: long + "\nnext\n",
+			wantFrames: []string{long[:maxSyslogMessage], "next\n"},
+		},
+		{
+			name:       "Digits beyond the maximum aren't a size",
+			givenInput: strings.Repeat("1", 2*maxSyslogMessage) + "\nnext\n",
+			wantFrames: []string{strings.Repeat("1", maxSyslogMessage), "next\n"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := bufio.NewReaderSize(strings.NewReader(test.givenInput), maxSyslogMessage)
+			var frames []string
+			for {
+				frame, err := readFrame(r)
+				if len(frame) > 0 {
+					frames = append(frames, frame)
+				}
+				if err != nil {
+					break
+				}
+			}
+			assert.Equal(t, test.wantFrames, frames)
+		})
+	}
+}